@@ -0,0 +1,268 @@
+// Package api serves a versioned REST API next to the MCP endpoint. Every
+// endpoint runs the matching MCP tool or reads the matching MCP resource
+// in-process, so validation, permissions and change notifications are exactly
+// those of the MCP server.
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Prefix is the path under which the API is mounted
+const Prefix = "/api/v1/"
+
+// openAPISpec describes the API; it is served at /api/v1/openapi.yaml
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Handler serves the REST API
+type Handler struct {
+	mcpServer  *server.MCPServer
+	jwtManager *auth.JWTManager
+	st         store.Store
+	mux        *http.ServeMux
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewHandler creates the API handler for a server with all tools and
+// resources registered
+func NewHandler(mcpServer *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) *Handler {
+	h := &Handler{mcpServer: mcpServer, jwtManager: jwtManager, st: st, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("GET /api/v1/openapi.yaml", h.serveOpenAPISpec)
+
+	h.mux.HandleFunc("GET /api/v1/tasks", h.listTasks)
+	h.mux.HandleFunc("POST /api/v1/tasks", h.createTask)
+	h.mux.HandleFunc("GET /api/v1/tasks/next", h.nextTask)
+	h.mux.HandleFunc("GET /api/v1/tasks/{id}", h.getTask)
+	h.mux.HandleFunc("POST /api/v1/tasks/{id}/complete", h.completeTask)
+	h.mux.HandleFunc("POST /api/v1/tasks/{id}/cancel", h.cancelTask)
+	h.mux.HandleFunc("GET /api/v1/tasks/{id}/progress", h.listProgress)
+	h.mux.HandleFunc("POST /api/v1/tasks/{id}/progress", h.reportProgress)
+	h.mux.HandleFunc("POST /api/v1/tasks/{id}/wait", h.waitForUser)
+	h.mux.HandleFunc("POST /api/v1/tasks/{id}/respond", h.respondToTask)
+	h.mux.HandleFunc("GET /api/v1/tasks/{id}/comments", h.listComments)
+
+	h.mux.HandleFunc("GET /api/v1/users", h.listUsers)
+	h.mux.HandleFunc("POST /api/v1/users", h.createUser)
+
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// serveOpenAPISpec serves the OpenAPI description of the API
+func (h *Handler) serveOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+// callTool runs a tool as the caller and writes its structured content with
+// the status, or the tool error with a matching status
+func (h *Handler) callTool(w http.ResponseWriter, r *http.Request, status int, name string, args map[string]any) {
+	params := mcp.CallToolParams{Name: name, Arguments: args}
+	result, ok := h.handle(w, r, mcp.MethodToolsCall, params)
+	if !ok {
+		return
+	}
+
+	callResult, err := mcp.ParseCallToolResult(&result)
+	if err != nil {
+		log.Printf("Error decoding %s result: %v", name, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if callResult.IsError {
+		writeError(w, errorStatus(tools.ResultErrorCode(callResult)), toolResultText(callResult))
+		return
+	}
+
+	writeJSON(w, status, callResult.StructuredContent)
+}
+
+// readResource reads a JSON resource as the caller and writes its contents
+func (h *Handler) readResource(w http.ResponseWriter, r *http.Request, uri string) {
+	params := mcp.ReadResourceParams{URI: uri}
+	result, ok := h.handle(w, r, mcp.MethodResourcesRead, params)
+	if !ok {
+		return
+	}
+
+	var readResult struct {
+		Contents []mcp.TextResourceContents `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &readResult); err != nil || len(readResult.Contents) == 0 {
+		log.Printf("Error decoding %s contents: %v", uri, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	// Resources are indented for humans; respond compact like the tools
+	writeJSON(w, http.StatusOK, json.RawMessage(readResult.Contents[0].Text))
+}
+
+// handle authenticates the caller and sends an MCP request on their behalf,
+// returning its raw result. On failure it writes the error response and
+// returns false.
+func (h *Handler) handle(w http.ResponseWriter, r *http.Request, method mcp.MCPMethod, params any) (json.RawMessage, bool) {
+	// Authenticate up front so that bad credentials get a 401
+	claims, err := tools.Authenticate(r.Context(), r.Header, h.jwtManager, h.st)
+	if err != nil {
+		writeError(w, errorStatus(tools.ErrorCodeOf(err)), err.Error())
+		return nil, false
+	}
+
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(method)},
+		Params:  params,
+	})
+	if err != nil {
+		log.Printf("Error encoding %s request: %v", method, err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+
+	// The tool or resource takes the caller from the context instead of
+	// validating the credential again
+	ctx := tools.WithCaller(r.Context(), claims)
+	ctx, resourceError := tools.WithErrorRecorder(ctx)
+	switch response := h.mcpServer.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		result, err := json.Marshal(response.Result)
+		if err != nil {
+			log.Printf("Error encoding %s result: %v", method, err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return nil, false
+		}
+		return result, true
+	case mcp.JSONRPCError:
+		// Resource errors reach JSON-RPC as a message only; the recorder
+		// keeps their code
+		status := http.StatusInternalServerError
+		if err := resourceError(); err != nil {
+			status = errorStatus(tools.ErrorCodeOf(err))
+		} else if response.Error.Code == mcp.RESOURCE_NOT_FOUND {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, response.Error.Message)
+		return nil, false
+	default:
+		log.Printf("Unexpected response to %s: %T", method, response)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return nil, false
+	}
+}
+
+// errorStatus maps the code of a tool or resource error to the HTTP status
+func errorStatus(code tools.ErrorCode) int {
+	switch code {
+	case tools.CodeInvalidInput:
+		return http.StatusBadRequest
+	case tools.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case tools.CodePermissionDenied:
+		return http.StatusForbidden
+	case tools.CodeNotFound:
+		return http.StatusNotFound
+	case tools.CodeConflict:
+		return http.StatusConflict
+	case tools.CodeConfirmationRequired:
+		return http.StatusPreconditionRequired
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// toolResultText joins the text contents of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := mcp.AsTextContent(content); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// decodeBody decodes a JSON object request body into tool arguments. An
+// empty body is an empty object; any other JSON value is rejected.
+func decodeBody(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	var body any
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err == io.EOF {
+		return map[string]any{}, true
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return nil, false
+	}
+	args, ok := body.(map[string]any)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "request body must be a JSON object")
+		return nil, false
+	}
+	return args, true
+}
+
+// queryList returns a comma-separated query parameter as a list, or nil
+func queryList(r *http.Request, name string) []any {
+	var items []any
+	for _, item := range strings.Split(r.URL.Query().Get(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// queryInt sets a numeric tool argument from a query parameter if present
+func queryInt(w http.ResponseWriter, r *http.Request, args map[string]any, name, arg string) bool {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be a number", name))
+		return false
+	}
+	args[arg] = n
+	return true
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Error writing API response: %v", err)
+	}
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/memory"
+	"github.com/dushes/simple-task-mcp/tools"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testAPI is an API handler on an in-memory store with one user
+type testAPI struct {
+	handler *Handler
+	st      store.Store
+	user    models.User
+	token   string
+}
+
+// newTestAPI creates the API with the tools and resources it uses
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	ctx := context.Background()
+
+	st := memory.New()
+	org, err := st.EnsureOrganization(ctx, "default")
+	if err != nil {
+		t.Fatalf("EnsureOrganization: %v", err)
+	}
+	user := models.User{OrgID: org.ID, Name: "alice"}
+	if err := st.CreateUser(ctx, &user, nil); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	jwtManager := auth.NewJWTManager("test-secret")
+	token, err := jwtManager.GenerateToken(user.ID, org.ID, false, 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	mcpServer := server.NewMCPServer("test", "0.0.0",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
+		server.WithToolHandlerMiddleware(tools.AuthMiddleware(jwtManager, st)),
+	)
+	for _, register := range []func(*server.MCPServer, *auth.JWTManager, store.Store) error{
+		tools.RegisterCreateTaskTool,
+		tools.RegisterCompleteTaskTool,
+		tools.RegisterCancelTaskTool,
+		tools.RegisterTaskResource,
+	} {
+		if err := register(mcpServer, jwtManager, st); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	return &testAPI{handler: NewHandler(mcpServer, jwtManager, st), st: st, user: user, token: token}
+}
+
+// createTask creates a pending task of the user assigned to themselves
+func (a *testAPI) createTask(t *testing.T) string {
+	t.Helper()
+	task := models.Task{
+		ID:          uuid.New().String(),
+		OrgID:       a.user.OrgID,
+		Description: "test task",
+		Status:      models.StatusPending,
+		CreatedBy:   a.user.ID,
+		AssignedTo:  a.user.ID,
+	}
+	if err := a.st.CreateTask(context.Background(), &task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task.ID
+}
+
+// do sends a request as the user and returns the recorded response
+func (a *testAPI) do(method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+a.token)
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestTaskActionBodies(t *testing.T) {
+	a := newTestAPI(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"empty", "", http.StatusOK},
+		{"object", `{"result": "done"}`, http.StatusOK},
+		{"null", "null", http.StatusBadRequest},
+		{"array", `["done"]`, http.StatusBadRequest},
+		{"string", `"done"`, http.StatusBadRequest},
+		{"malformed", `{"result": `, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID := a.createTask(t)
+			w := a.do(http.MethodPost, "/api/v1/tasks/"+taskID+"/complete", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestCreateTaskBodies(t *testing.T) {
+	a := newTestAPI(t)
+
+	for _, body := range []string{"", "null", "[]", "{"} {
+		w := a.do(http.MethodPost, "/api/v1/tasks", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %q: status = %d, want %d (body %s)", body, w.Code, http.StatusBadRequest, w.Body)
+		}
+	}
+}
+
+// countingStore counts how often API keys are validated
+type countingStore struct {
+	store.Store
+	touches int
+}
+
+func (s *countingStore) TouchAPIKey(ctx context.Context, keyID string) error {
+	s.touches++
+	return s.Store.TouchAPIKey(ctx, keyID)
+}
+
+func TestAPIKeyValidatedOnce(t *testing.T) {
+	a := newTestAPI(t)
+	counting := &countingStore{Store: a.st}
+	a.handler.st = counting
+
+	keyID := uuid.New().String()
+	apiKey, keyHash, err := auth.GenerateAPIKey(keyID)
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if err := a.st.CreateAPIKey(context.Background(), &models.APIKey{ID: keyID, UserID: a.user.ID, Name: "test", KeyHash: keyHash}); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	taskID := a.createTask(t)
+	for _, path := range []string{"/api/v1/tasks/" + taskID, "/api/v1/tasks/" + taskID + "/complete"} {
+		counting.touches = 0
+		method := http.MethodGet
+		if strings.HasSuffix(path, "/complete") {
+			method = http.MethodPost
+		}
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		a.handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status = %d (body %s)", method, path, w.Code, w.Body)
+		}
+		if counting.touches != 1 {
+			t.Errorf("%s %s: API key validated %d times, want 1", method, path, counting.touches)
+		}
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	a := newTestAPI(t)
+	ctx := context.Background()
+
+	// A task of another user that alice may not see or change
+	bob := models.User{OrgID: a.user.OrgID, Name: "bob"}
+	if err := a.st.CreateUser(ctx, &bob, nil); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	hidden := models.Task{
+		ID:          uuid.New().String(),
+		OrgID:       a.user.OrgID,
+		Description: "bob's task",
+		Status:      models.StatusPending,
+		CreatedBy:   bob.ID,
+		AssignedTo:  bob.ID,
+	}
+	if err := a.st.CreateTask(ctx, &hidden); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+
+	completed := a.createTask(t)
+	if w := a.do(http.MethodPost, "/api/v1/tasks/"+completed+"/complete", ""); w.Code != http.StatusOK {
+		t.Fatalf("complete: status = %d (body %s)", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"invalid tool input", http.MethodPost, "/api/v1/tasks/not-a-uuid/complete", "", http.StatusBadRequest},
+		{"tool not found", http.MethodPost, "/api/v1/tasks/" + uuid.New().String() + "/complete", "", http.StatusNotFound},
+		{"tool permission denied", http.MethodPost, "/api/v1/tasks/" + hidden.ID + "/complete", "", http.StatusForbidden},
+		{"tool conflict", http.MethodPost, "/api/v1/tasks/" + completed + "/complete", "", http.StatusConflict},
+		{"invalid resource", http.MethodGet, "/api/v1/tasks/not-a-uuid", "", http.StatusBadRequest},
+		{"resource not found", http.MethodGet, "/api/v1/tasks/" + uuid.New().String(), "", http.StatusNotFound},
+		{"resource permission denied", http.MethodGet, "/api/v1/tasks/" + hidden.ID, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(tt.method, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+completed, nil)
+	r.Header.Set("Authorization", "Bearer invalid")
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token: status = %d, want %d (body %s)", w.Code, http.StatusUnauthorized, w.Body)
+	}
+}
+
+func TestCancelConfirmation(t *testing.T) {
+	a := newTestAPI(t)
+	// The requirement is global; no other test of this package cancels tasks
+	if err := tools.RequireConfirmation([]string{"cancel_task"}); err != nil {
+		t.Fatalf("RequireConfirmation: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"unconfirmed", `{"reason": "obsolete"}`, http.StatusPreconditionRequired},
+		{"declined", `{"reason": "obsolete", "confirm": false}`, http.StatusPreconditionRequired},
+		{"not a boolean", `{"reason": "obsolete", "confirm": "yes"}`, http.StatusBadRequest},
+		{"confirmed", `{"reason": "obsolete", "confirm": true}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID := a.createTask(t)
+			w := a.do(http.MethodPost, "/api/v1/tasks/"+taskID+"/cancel", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dushes/simple-task-mcp/tools"
+)
+
+// listTasks lists the tasks created by the caller, a user or a team
+// (list_created_tasks)
+func (h *Handler) listTasks(w http.ResponseWriter, r *http.Request) {
+	args := map[string]any{}
+	if statuses := queryList(r, "status"); statuses != nil {
+		args["statuses"] = statuses
+	}
+	if user := r.URL.Query().Get("user"); user != "" {
+		args["user_name"] = user
+	}
+	if team := r.URL.Query().Get("team"); team != "" {
+		args["team_name"] = team
+	}
+	if !queryInt(w, r, args, "limit", "limit") {
+		return
+	}
+	h.callTool(w, r, http.StatusOK, "list_created_tasks", args)
+}
+
+// createTask creates a task (create_task)
+func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
+	args, ok := decodeBody(w, r)
+	if !ok {
+		return
+	}
+	h.callTool(w, r, http.StatusCreated, "create_task", args)
+}
+
+// nextTask returns the next task assigned to the caller, optionally waiting
+// for one (get_next_task)
+func (h *Handler) nextTask(w http.ResponseWriter, r *http.Request) {
+	args := map[string]any{}
+	if statuses := queryList(r, "status"); statuses != nil {
+		args["statuses"] = statuses
+	}
+	if !queryInt(w, r, args, "wait_seconds", "wait_seconds") {
+		return
+	}
+	h.callTool(w, r, http.StatusOK, "get_next_task", args)
+}
+
+// getTask returns a task with its comments (task://{id})
+func (h *Handler) getTask(w http.ResponseWriter, r *http.Request) {
+	h.readResource(w, r, fmt.Sprintf("task://%s", r.PathValue("id")))
+}
+
+// listComments returns the comments of a task (task://{id}/comments)
+func (h *Handler) listComments(w http.ResponseWriter, r *http.Request) {
+	h.readResource(w, r, fmt.Sprintf("task://%s/comments", r.PathValue("id")))
+}
+
+// listProgress returns the progress history of a task (task://{id}/progress)
+func (h *Handler) listProgress(w http.ResponseWriter, r *http.Request) {
+	h.readResource(w, r, fmt.Sprintf("task://%s/progress", r.PathValue("id")))
+}
+
+// completeTask completes a task (complete_task)
+func (h *Handler) completeTask(w http.ResponseWriter, r *http.Request) {
+	h.taskAction(w, r, "complete_task")
+}
+
+// cancelTask cancels a task (cancel_task). MCP clients are asked to confirm
+// the cancellation; REST clients confirm it with "confirm": true instead.
+func (h *Handler) cancelTask(w http.ResponseWriter, r *http.Request) {
+	args, ok := decodeBody(w, r)
+	if !ok {
+		return
+	}
+	if value, ok := args["confirm"]; ok {
+		confirmed, isBool := value.(bool)
+		if !isBool {
+			writeError(w, http.StatusBadRequest, "confirm must be a boolean")
+			return
+		}
+		if confirmed {
+			r = r.WithContext(tools.WithConfirmation(r.Context()))
+		}
+		delete(args, "confirm")
+	}
+	args["id"] = r.PathValue("id")
+	h.callTool(w, r, http.StatusOK, "cancel_task", args)
+}
+
+// reportProgress reports progress on a task (report_progress)
+func (h *Handler) reportProgress(w http.ResponseWriter, r *http.Request) {
+	h.taskAction(w, r, "report_progress")
+}
+
+// waitForUser asks the creator of a task a question (wait_for_user)
+func (h *Handler) waitForUser(w http.ResponseWriter, r *http.Request) {
+	h.taskAction(w, r, "wait_for_user")
+}
+
+// respondToTask answers a task waiting for user (respond_to_task)
+func (h *Handler) respondToTask(w http.ResponseWriter, r *http.Request) {
+	h.taskAction(w, r, "respond_to_task")
+}
+
+// taskAction runs a tool that changes the task in the path, with the body as
+// the other arguments
+func (h *Handler) taskAction(w http.ResponseWriter, r *http.Request, tool string) {
+	args, ok := decodeBody(w, r)
+	if !ok {
+		return
+	}
+	args["id"] = r.PathValue("id")
+	h.callTool(w, r, http.StatusOK, tool, args)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+)
+
+// listUsers lists the users of the caller's organization (list_users)
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	args := map[string]any{}
+	if !queryInt(w, r, args, "limit", "limit") {
+		return
+	}
+	h.callTool(w, r, http.StatusOK, "list_users", args)
+}
+
+// createUser creates a user and returns its token (create_user)
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	args, ok := decodeBody(w, r)
+	if !ok {
+		return
+	}
+	h.callTool(w, r, http.StatusCreated, "create_user", args)
+}
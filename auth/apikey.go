@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// APIKeyPrefix marks a credential as an API key rather than a JWT
+const APIKeyPrefix = "stk_"
+
+// ErrInvalidAPIKey is returned when an API key is malformed or does not match
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// IsAPIKey reports whether the credential looks like an API key
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// GenerateAPIKey creates a new API key for the key record with the given ID.
+// It returns the plaintext key, which is shown to the user once, and the
+// bcrypt hash of its secret part, which is stored in the database.
+func GenerateAPIKey(keyID string) (key string, hash string, err error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	hashBytes, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+
+	return APIKeyPrefix + keyID + "_" + secret, string(hashBytes), nil
+}
+
+// ParseAPIKey splits an API key into the key record ID and its secret part
+func ParseAPIKey(key string) (keyID string, secret string, err error) {
+	if !IsAPIKey(key) {
+		return "", "", ErrInvalidAPIKey
+	}
+
+	keyID, secret, found := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !found || keyID == "" || secret == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+
+	return keyID, secret, nil
+}
+
+// VerifyAPIKeySecret checks the secret part of an API key against its stored hash
+func VerifyAPIKeySecret(hash, secret string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)); err != nil {
+		return ErrInvalidAPIKey
+	}
+	return nil
+}
+
+// NewAPIKeyClaims builds the claims for a caller authenticated with an API key.
+// API keys have no expiry; they stay valid until revoked.
+func NewAPIKeyClaims(userID string, orgID string, keyID string, createdAt time.Time) *Claims {
+	return &Claims{
+		UserID:   userID,
+		OrgID:    orgID,
+		APIKeyID: keyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt: jwt.NewNumericDate(createdAt),
+		},
+	}
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+)
+
+// contextKey is an unexported type for keys defined in this package
+type contextKey int
+
+const (
+	tokenContextKey contextKey = iota
+	claimsContextKey
+)
+
+// WithToken returns a copy of ctx that carries the given authentication token
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey, token)
+}
+
+// TokenFromContext returns the authentication token stored in ctx, if any
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenContextKey).(string)
+	return token
+}
+
+// WithClaims returns a copy of ctx that carries the validated token claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// ClaimsFromContext returns the validated claims stored in ctx, if any
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(*Claims)
+	return claims, ok && claims != nil
+}
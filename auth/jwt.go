@@ -10,8 +10,17 @@ import (
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID  string `json:"user_id"`
+	UserID string `json:"user_id"`
+	// OrgID is the organization of the user; all data access is scoped to it
+	OrgID   string `json:"org_id"`
 	IsAdmin bool   `json:"is_admin"`
+	// APIKeyID is set when the caller authenticated with an API key
+	APIKeyID string `json:"-"`
+	// Roles are loaded from the database on every request, not stored in the token
+	Roles []Role `json:"-"`
+	// TokenGeneration is the token generation of the user when the token was
+	// issued; revoking the user's tokens moves the user to a new generation
+	TokenGeneration int `json:"gen,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -27,11 +36,13 @@ func NewJWTManager(secretKey string) *JWTManager {
 	}
 }
 
-// GenerateToken creates a new JWT token for a user
-func (j *JWTManager) GenerateToken(userID string, isAdmin bool) (string, error) {
+// GenerateToken creates a new JWT token for a user of the token generation
+func (j *JWTManager) GenerateToken(userID string, orgID string, isAdmin bool, generation int) (string, error) {
 	claims := &Claims{
-		UserID:  userID,
-		IsAdmin: isAdmin,
+		UserID:          userID,
+		OrgID:           orgID,
+		IsAdmin:         isAdmin,
+		TokenGeneration: generation,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(365 * 24 * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
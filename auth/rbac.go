@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Role is a named set of permissions assigned to users
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleManager Role = "manager"
+	RoleAgent   Role = "agent"
+	RoleViewer  Role = "viewer"
+)
+
+// DefaultRole is applied to users that have no roles assigned
+const DefaultRole = RoleAgent
+
+// Permission is a single action that can be granted to a role
+type Permission string
+
+const (
+	PermUsersRead      Permission = "users:read"
+	PermUsersManage    Permission = "users:manage"
+	PermTasksRead      Permission = "tasks:read"
+	PermTasksReadAll   Permission = "tasks:read_all"
+	PermTasksCreate    Permission = "tasks:create"
+	PermTasksWork      Permission = "tasks:work"
+	PermTasksManageAll Permission = "tasks:manage_all"
+	PermAPIKeysOwn     Permission = "api_keys:own"
+)
+
+// allPermissions lists every permission in display order
+var allPermissions = []Permission{
+	PermUsersRead, PermUsersManage,
+	PermTasksRead, PermTasksReadAll, PermTasksCreate, PermTasksWork, PermTasksManageAll,
+	PermAPIKeysOwn,
+}
+
+// rolePermissions is the permission matrix
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: allPermissions,
+	RoleManager: {
+		PermUsersRead,
+		PermTasksRead, PermTasksReadAll, PermTasksCreate, PermTasksWork,
+		PermAPIKeysOwn,
+	},
+	RoleAgent: {
+		PermUsersRead,
+		PermTasksRead, PermTasksCreate, PermTasksWork,
+		PermAPIKeysOwn,
+	},
+	RoleViewer: {
+		PermUsersRead,
+		PermTasksRead,
+	},
+}
+
+// IsValidRole checks if the given role exists
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[Role(role)]
+	return ok
+}
+
+// ValidRoles returns all role names in sorted order
+func ValidRoles() []string {
+	roles := make([]string, 0, len(rolePermissions))
+	for role := range rolePermissions {
+		roles = append(roles, string(role))
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// EffectiveRoles combines the admin flag with the assigned roles, falling back
+// to DefaultRole when the user has none
+func EffectiveRoles(isAdmin bool, roles []Role) []Role {
+	var effective []Role
+	if isAdmin {
+		effective = append(effective, RoleAdmin)
+	}
+	for _, role := range roles {
+		if role != RoleAdmin {
+			effective = append(effective, role)
+		}
+	}
+	if len(effective) == 0 {
+		effective = append(effective, DefaultRole)
+	}
+	return effective
+}
+
+// HasPermission reports whether any of the caller's roles grants the permission
+func (c *Claims) HasPermission(permission Permission) bool {
+	roles := c.Roles
+	if len(roles) == 0 {
+		roles = EffectiveRoles(c.IsAdmin, nil)
+	}
+
+	for _, role := range roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Permissions returns all permissions granted to the caller
+func (c *Claims) Permissions() []string {
+	var permissions []string
+	for _, permission := range allPermissions {
+		if c.HasPermission(permission) {
+			permissions = append(permissions, string(permission))
+		}
+	}
+	return permissions
+}
+
+// ErrPermissionDenied is wrapped by the errors of Authorize
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Authorize returns an error unless the caller has the permission
+func Authorize(claims *Claims, permission Permission) error {
+	if claims == nil || !claims.HasPermission(permission) {
+		return fmt.Errorf("%w: requires %s", ErrPermissionDenied, permission)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/backend"
+)
+
+// setupHealth checks that the database is reachable, that its schema is up to
+// date and that it can be queried; it fails if any check fails
+func setupHealth(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		// The store connects when it is opened
+		fmt.Println("Connection: ok")
+
+		problems := 0
+
+		migrator, err := backend.Migrations(e.st)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check migrations: %w", err)
+		}
+		var applied, pending, changed, missing int
+		for _, status := range statuses {
+			switch {
+			case status.Missing:
+				missing++
+			case status.Changed:
+				changed++
+			case status.Applied:
+				applied++
+			default:
+				pending++
+			}
+		}
+		migrationState := "ok"
+		if pending+changed+missing > 0 {
+			migrationState = "not up to date"
+			problems++
+		}
+		fmt.Printf("Migrations: %s (%d applied, %d pending, %d changed, %d missing)\n", migrationState, applied, pending, changed, missing)
+
+		org, err := e.st.GetOrganizationByName(ctx, e.orgName)
+		switch {
+		case err == store.ErrNotFound:
+			fmt.Printf("Organization %s: does not exist\n", e.orgName)
+		case err != nil:
+			fmt.Printf("Organization %s: query failed: %v\n", e.orgName, err)
+			problems++
+		default:
+			openTasks, err := e.st.CountTasks(ctx, store.TaskFilter{
+				OrgID:           org.ID,
+				Statuses:        []models.TaskStatus{models.StatusPending, models.StatusInProgress, models.StatusWaitingForUser},
+				ExcludeArchived: true,
+			})
+			if err != nil {
+				fmt.Printf("Organization %s: query failed: %v\n", e.orgName, err)
+				problems++
+				break
+			}
+			fmt.Printf("Organization %s: ok (%d open tasks)\n", org.Name, openTasks)
+		}
+
+		if problems > 0 {
+			return fmt.Errorf("%d checks failed", problems)
+		}
+		return nil
+	}
+}
@@ -1,68 +1,178 @@
+// Command create-admin manages users, tokens and tasks directly in the
+// database, without going through the MCP server. Run without a command it
+// creates the initial admin user.
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/dushes/simple-task-mcp/auth"
 	"github.com/dushes/simple-task-mcp/config"
 	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/internal/cli"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/backend"
+	"github.com/google/uuid"
 )
 
+// env is what the commands work with
+type env struct {
+	cfg        *config.Config
+	st         store.Store
+	jwtManager *auth.JWTManager
+	orgName    string
+}
+
+// command is a subcommand of the CLI
+type command struct {
+	name        string
+	args        []string
+	description string
+	// skipMigrations is set for commands that must not change the schema
+	skipMigrations bool
+	// setup registers the flags of the command and returns the function that
+	// runs it with the positional arguments
+	setup func(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error
+}
+
+// commands in the order of the usage text
+var commands = []command{
+	{name: "admin", description: "Create the initial admin user (default command)", setup: setupAdmin},
+	{name: "create-user", args: []string{"NAME"}, description: "Create a user and print its token", setup: setupCreateUser},
+	{name: "issue-token", args: []string{"USER"}, description: "Print a new JWT token for a user", setup: setupIssueToken},
+	{name: "revoke-tokens", args: []string{"USER"}, description: "Reject the JWT tokens issued to a user so far", setup: setupRevokeTokens},
+	{name: "create-api-key", args: []string{"USER"}, description: "Create a long-lived API key for a user", setup: setupCreateAPIKey},
+	{name: "revoke-api-key", args: []string{"KEY_ID"}, description: "Revoke an API key", setup: setupRevokeAPIKey},
+	{name: "list-tasks", description: "List the tasks of the organization", setup: setupListTasks},
+	{name: "reassign-task", args: []string{"TASK_ID", "USER"}, description: "Assign an open task to another user", setup: setupReassignTask},
+	{name: "health", description: "Check the database connection and migrations", skipMigrations: true, setup: setupHealth},
+}
+
 func main() {
 	// Configure logging to match main application
 	log.SetFlags(log.Ldate | log.Ltime)
 
+	// The admin command runs when the first argument is not a command, so
+	// that plain create-admin keeps working
+	name, args := "admin", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	// Parse command line flags
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	orgName := flags.String("org", "default", "Organization to work in; the admin and create-user commands create it if it does not exist")
+	migrationsDir := flags.String("migrations-dir", "", "Read migrations from this directory instead of the ones built into the binary")
+	skipMigrations := flags.Bool("skip-migrations", false, "Do not apply pending migrations before running the command")
+	run := cmd.setup(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", os.Args[0], cmd.name, strings.Join(cmd.args, " "), cmd.description)
+		flags.PrintDefaults()
+	}
+	params := cli.ParseInterspersed(flags, args)
+	if len(params) != len(cmd.args) {
+		flags.Usage()
+		os.Exit(2)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Connect to database
-	if err := database.Connect(cfg.DatabaseURL); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// The server seeds its own admin into a fresh in-memory store
+	if backend.IsMemory(cfg.DatabaseURL) {
+		log.Fatalf("DATABASE_URL selects the in-memory store; the server prints an admin token at startup instead")
 	}
-	defer database.Close()
+
+	// Open the store
+	st, err := backend.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+	defer st.Close()
 
 	// Run migrations
-	if err := database.RunMigrations(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	ctx := context.Background()
+	database.SetMigrationsDir(*migrationsDir)
+	if !cmd.skipMigrations && !*skipMigrations {
+		if err := st.Migrate(ctx); err != nil {
+			log.Fatalf("Failed to run migrations: %v", err)
+		}
 	}
 
-	// Create initial admin user
-	var userID string
-	query := `
-		INSERT INTO users (name, is_admin)
-		VALUES ($1, $2)
-		ON CONFLICT DO NOTHING
-		RETURNING id
-	`
+	e := &env{
+		cfg:        cfg,
+		st:         st,
+		jwtManager: auth.NewJWTManager(cfg.JWTSecret),
+		orgName:    *orgName,
+	}
+	if err := run(ctx, e, params); err != nil {
+		log.Fatalf("%s: %v", cmd.name, err)
+	}
+}
 
-	err = database.DB.QueryRow(query, "admin", true).Scan(&userID)
-	if err != nil {
-		// Check if admin already exists
-		checkQuery := `SELECT id FROM users WHERE name = $1 AND is_admin = true`
-		err = database.DB.QueryRow(checkQuery, "admin").Scan(&userID)
-		if err != nil {
-			log.Fatalf("Failed to create or find admin user: %v", err)
+// findCommand looks up a command by name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
 		}
-		fmt.Println("Admin user already exists")
-	} else {
-		fmt.Println("Admin user created successfully")
 	}
+	return command{}, false
+}
+
+// printUsage prints the list of commands
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", strings.TrimSpace(cmd.name+" "+strings.Join(cmd.args, " ")), cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
 
-	// Generate JWT token for admin
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret)
-	token, err := jwtManager.GenerateToken(userID, true)
+// findOrganization looks up the organization selected with -org
+func (e *env) findOrganization(ctx context.Context) (*models.Organization, error) {
+	org, err := e.st.GetOrganizationByName(ctx, e.orgName)
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("organization '%s' does not exist", e.orgName)
+	}
 	if err != nil {
-		log.Fatalf("Failed to generate token: %v", err)
+		return nil, fmt.Errorf("failed to find organization: %w", err)
+	}
+	return org, nil
+}
+
+// validateID checks that an ID given on the command line is a UUID, so that it
+// is not sent to the database as is
+func validateID(kind, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("'%s' is not a valid %s ID", id, kind)
 	}
+	return nil
+}
 
-	fmt.Println("\n=== Initial Admin Credentials ===")
-	fmt.Printf("User ID: %s\n", userID)
-	fmt.Printf("Name: admin\n")
-	fmt.Printf("Is Admin: true\n")
-	fmt.Printf("\nJWT Token:\n%s\n", token)
-	fmt.Println("\nUse this token in the 'auth_token' parameter when calling admin-only tools.")
+// findUser looks up a user by name in the organization
+func (e *env) findUser(ctx context.Context, org *models.Organization, name string) (*models.UserWithRoles, error) {
+	user, err := e.st.GetUserByName(ctx, org.ID, name)
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("user '%s' does not exist in organization '%s'", name, org.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user, nil
 }
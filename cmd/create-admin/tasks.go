@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dushes/simple-task-mcp/internal/cli"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// setupListTasks prints a table of the tasks of the organization, newest first
+func setupListTasks(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	statusList := flags.String("status", "", "Comma-separated statuses to list, e.g. pending,in_progress")
+	assignedTo := flags.String("assigned-to", "", "Only tasks assigned to this user")
+	createdBy := flags.String("created-by", "", "Only tasks created by this user")
+	teamName := flags.String("team", "", "Only tasks of this team")
+	archived := flags.Bool("archived", false, "Include archived tasks")
+	limit := flags.Int("limit", 50, "Maximum number of tasks to list")
+
+	return func(ctx context.Context, e *env, args []string) error {
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+
+		filter := store.TaskFilter{
+			OrgID:           org.ID,
+			ExcludeArchived: !*archived,
+			NewestFirst:     true,
+			Limit:           *limit,
+		}
+		for _, status := range strings.Split(*statusList, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !models.IsValidStatus(status) {
+				return fmt.Errorf("invalid status '%s'", status)
+			}
+			filter.Statuses = append(filter.Statuses, models.TaskStatus(status))
+		}
+		if *assignedTo != "" {
+			user, err := e.findUser(ctx, org, *assignedTo)
+			if err != nil {
+				return err
+			}
+			filter.AssignedTo = user.ID
+		}
+		if *createdBy != "" {
+			user, err := e.findUser(ctx, org, *createdBy)
+			if err != nil {
+				return err
+			}
+			filter.CreatedBy = user.ID
+		}
+		if *teamName != "" {
+			team, err := e.st.GetTeamByName(ctx, org.ID, *teamName)
+			if err == store.ErrNotFound {
+				return fmt.Errorf("team '%s' does not exist", *teamName)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to find team: %w", err)
+			}
+			filter.TeamID = team.ID
+		}
+
+		tasks, err := e.st.ListTasks(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+		total, err := e.st.CountTasks(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to count tasks: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tASSIGNED TO\tTEAM\tCREATED BY\tCREATED AT\tDESCRIPTION")
+		for _, task := range tasks {
+			team := "-"
+			if task.AssignedTeam != nil {
+				team = *task.AssignedTeam
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				task.ID, task.Status, task.AssignedTo, team, task.CreatedBy, task.CreatedAt, cli.Summarize(task.Description))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nShowing %d of %d tasks\n", len(tasks), total)
+		return nil
+	}
+}
+
+// setupReassignTask assigns an open task to another user of the organization
+func setupReassignTask(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		taskID, userName := args[0], args[1]
+		if err := validateID("task", taskID); err != nil {
+			return err
+		}
+
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+		task, err := e.st.GetTask(ctx, org.ID, taskID)
+		if err == store.ErrNotFound {
+			return fmt.Errorf("task '%s' does not exist in organization '%s'", taskID, org.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find task: %w", err)
+		}
+		assignee, err := e.findUser(ctx, org, userName)
+		if err != nil {
+			return err
+		}
+		if assignee.IsDisabled {
+			return fmt.Errorf("user '%s' is disabled", assignee.Name)
+		}
+
+		err = e.st.ReassignTask(ctx, task.ID, assignee.ID)
+		if err == store.ErrTaskClosed {
+			return fmt.Errorf("cannot reassign %s task", task.Status)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to reassign task: %w", err)
+		}
+
+		fmt.Printf("Task reassigned from %s to %s: %s (ID: %s)\n", task.AssignedTo, assignee.Name, cli.Summarize(task.Description), task.ID)
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/google/uuid"
+)
+
+// setupIssueToken prints a new JWT token for a user, e.g. to replace a lost one
+func setupIssueToken(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+		user, err := e.findUser(ctx, org, args[0])
+		if err != nil {
+			return err
+		}
+		if user.IsDisabled {
+			return fmt.Errorf("user '%s' is disabled", user.Name)
+		}
+
+		token, err := e.jwtManager.GenerateToken(user.ID, org.ID, user.IsAdmin, user.TokenGeneration)
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+
+		// Only the token goes to stdout so that scripts can capture it
+		fmt.Println(token)
+		return nil
+	}
+}
+
+// setupRevokeTokens rejects the tokens issued to a user so far, e.g. when one
+// leaked; tokens issued afterwards and API keys keep working
+func setupRevokeTokens(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+		user, err := e.findUser(ctx, org, args[0])
+		if err != nil {
+			return err
+		}
+
+		if err := e.st.RevokeTokens(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to revoke tokens: %w", err)
+		}
+
+		fmt.Printf("Tokens of %s revoked (ID: %s). Issue a new one with issue-token; API keys are revoked with revoke-api-key.\n", user.Name, user.ID)
+		return nil
+	}
+}
+
+// setupCreateAPIKey creates a long-lived API key for a user, like the
+// create_api_key tool
+func setupCreateAPIKey(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	name := flags.String("name", "", "Name describing what the key is used for, e.g. 'nightly-cron' (required)")
+
+	return func(ctx context.Context, e *env, args []string) error {
+		if *name == "" {
+			return fmt.Errorf("-name is required")
+		}
+
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+		owner, err := e.findUser(ctx, org, args[0])
+		if err != nil {
+			return err
+		}
+
+		// Generate the key; only its hash is stored
+		keyID := uuid.New().String()
+		apiKey, keyHash, err := auth.GenerateAPIKey(keyID)
+		if err != nil {
+			return fmt.Errorf("failed to generate API key: %w", err)
+		}
+
+		key := models.APIKey{ID: keyID, UserID: owner.ID, Name: *name, KeyHash: keyHash}
+		if err := e.st.CreateAPIKey(ctx, &key); err != nil {
+			return fmt.Errorf("failed to create API key: %w", err)
+		}
+
+		fmt.Printf("API key created: %s for %s (ID: %s)\n", *name, owner.Name, keyID)
+		fmt.Printf("\nAPI Key:\n%s\n", apiKey)
+		fmt.Println("\nStore this key securely, it cannot be retrieved again. Send it in the X-API-Key header.")
+		return nil
+	}
+}
+
+// setupRevokeAPIKey revokes an API key of the organization
+func setupRevokeAPIKey(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		keyID := args[0]
+		if err := validateID("API key", keyID); err != nil {
+			return err
+		}
+
+		org, err := e.findOrganization(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Keys are looked up by ID, so check that the owner is in the organization
+		key, err := e.st.GetAPIKey(ctx, keyID)
+		if err != nil && err != store.ErrNotFound {
+			return fmt.Errorf("failed to find API key: %w", err)
+		}
+		var owner *models.UserWithRoles
+		if err == nil {
+			owner, err = e.st.GetUser(ctx, key.UserID)
+			if err != nil && err != store.ErrNotFound {
+				return fmt.Errorf("failed to find API key owner: %w", err)
+			}
+		}
+		if owner == nil || owner.OrgID != org.ID {
+			return fmt.Errorf("API key '%s' does not exist in organization '%s'", keyID, org.Name)
+		}
+		if key.RevokedAt.Valid {
+			return fmt.Errorf("API key '%s' is already revoked", keyID)
+		}
+
+		revokedAt, err := e.st.RevokeAPIKey(ctx, keyID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke API key: %w", err)
+		}
+
+		fmt.Printf("API key revoked: %s of %s (ID: %s) at %s\n", key.Name, owner.Name, keyID, revokedAt.Format("2006-01-02T15:04:05Z"))
+		return nil
+	}
+}
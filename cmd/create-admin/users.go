@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// setupAdmin creates the initial admin user unless it already exists
+func setupAdmin(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	return func(ctx context.Context, e *env, args []string) error {
+		// Find or create the organization
+		org, err := e.st.EnsureOrganization(ctx, e.orgName)
+		if err != nil {
+			return fmt.Errorf("failed to create or find organization: %w", err)
+		}
+
+		// Create initial admin user unless it already exists
+		var userID string
+		var generation int
+		existing, err := e.st.GetUserByName(ctx, org.ID, "admin")
+		switch {
+		case err == nil && existing.IsAdmin:
+			userID, generation = existing.ID, existing.TokenGeneration
+			fmt.Println("Admin user already exists")
+		case err == nil:
+			return fmt.Errorf("failed to create or find admin user: user 'admin' exists but is not an admin")
+		case err == store.ErrNotFound:
+			admin := models.User{OrgID: org.ID, Name: "admin", IsAdmin: true}
+			if err := e.st.CreateUser(ctx, &admin, nil); err != nil {
+				return fmt.Errorf("failed to create or find admin user: %w", err)
+			}
+			userID = admin.ID
+			fmt.Println("Admin user created successfully")
+		default:
+			return fmt.Errorf("failed to create or find admin user: %w", err)
+		}
+
+		// Generate JWT token for admin
+		token, err := e.jwtManager.GenerateToken(userID, org.ID, true, generation)
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+
+		fmt.Println("\n=== Initial Admin Credentials ===")
+		fmt.Printf("Organization: %s (ID: %s)\n", org.Name, org.ID)
+		fmt.Printf("User ID: %s\n", userID)
+		fmt.Printf("Name: admin\n")
+		fmt.Printf("Is Admin: true\n")
+		fmt.Printf("\nJWT Token:\n%s\n", token)
+		fmt.Println("\nUse this token in the 'auth_token' parameter when calling admin-only tools.")
+		return nil
+	}
+}
+
+// setupCreateUser creates a user with any name, like the create_user tool
+func setupCreateUser(flags *flag.FlagSet) func(ctx context.Context, e *env, args []string) error {
+	description := flags.String("description", "", "Description of the user")
+	isAdmin := flags.Bool("admin", false, "Give the user admin privileges")
+	roleList := flags.String("roles", "", fmt.Sprintf("Comma-separated roles (%s); the user gets the '%s' role if empty", strings.Join(auth.ValidRoles(), ", "), auth.DefaultRole))
+
+	return func(ctx context.Context, e *env, args []string) error {
+		name := args[0]
+
+		// The admin role is the same as -admin
+		admin := *isAdmin
+		var roles []auth.Role
+		for _, role := range strings.Split(*roleList, ",") {
+			role = strings.TrimSpace(role)
+			switch {
+			case role == "":
+			case !auth.IsValidRole(role):
+				return fmt.Errorf("invalid role '%s', valid roles are: %s", role, strings.Join(auth.ValidRoles(), ", "))
+			case auth.Role(role) == auth.RoleAdmin:
+				admin = true
+			default:
+				roles = append(roles, auth.Role(role))
+			}
+		}
+
+		org, err := e.st.EnsureOrganization(ctx, e.orgName)
+		if err != nil {
+			return fmt.Errorf("failed to create or find organization: %w", err)
+		}
+
+		user := models.User{OrgID: org.ID, Name: name, Description: *description, IsAdmin: admin}
+		if err := e.st.CreateUser(ctx, &user, roles); err != nil {
+			if err == store.ErrDuplicate {
+				return fmt.Errorf("user '%s' already exists in organization '%s'", name, org.Name)
+			}
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		token, err := e.jwtManager.GenerateToken(user.ID, org.ID, admin, user.TokenGeneration)
+		if err != nil {
+			return fmt.Errorf("failed to generate token: %w", err)
+		}
+
+		fmt.Println("User created successfully")
+		fmt.Println("\n=== User Credentials ===")
+		fmt.Printf("Organization: %s (ID: %s)\n", org.Name, org.ID)
+		fmt.Printf("User ID: %s\n", user.ID)
+		fmt.Printf("Name: %s\n", name)
+		fmt.Printf("Is Admin: %t\n", admin)
+		fmt.Printf("Roles: %s\n", roleNames(auth.EffectiveRoles(admin, roles)))
+		fmt.Printf("\nJWT Token:\n%s\n", token)
+		return nil
+	}
+}
+
+// roleNames joins roles for display
+func roleNames(roles []auth.Role) string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// taskClient is an initialized MCP client session with the server
+type taskClient struct {
+	mcp *client.Client
+	// json prints responses as JSON instead of tables
+	json bool
+}
+
+// connect opens an MCP session with the server, authenticated with the token
+// or, if it is empty, the API key
+func connect(ctx context.Context, serverURL, token, apiKey string, jsonOutput bool) (*taskClient, error) {
+	headers := map[string]string{}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	} else {
+		headers["X-API-Key"] = apiKey
+	}
+
+	mcpClient, err := client.NewStreamableHttpClient(serverURL, transport.WithHTTPHeaders(headers))
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if err := mcpClient.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", serverURL, err)
+	}
+
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "taskctl", Version: "0.1.0"}
+	if _, err := mcpClient.Initialize(ctx, initRequest); err != nil {
+		mcpClient.Close()
+		return nil, fmt.Errorf("failed to initialize session with %s: %w", serverURL, err)
+	}
+
+	return &taskClient{mcp: mcpClient, json: jsonOutput}, nil
+}
+
+// Close ends the session
+func (c *taskClient) Close() error {
+	return c.mcp.Close()
+}
+
+// callTool calls a tool and decodes its structured content into out. A tool
+// error is returned as an error with the message of the tool.
+func (c *taskClient) callTool(ctx context.Context, name string, args map[string]any, out any) error {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+
+	result, err := c.mcp.CallTool(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", name, err)
+	}
+	if result.IsError {
+		return errors.New(resultText(result.Content))
+	}
+	if result.StructuredContent == nil {
+		return fmt.Errorf("%s returned no structured content", name)
+	}
+
+	// Structured content arrives as generic JSON values
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", name, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", name, err)
+	}
+	return nil
+}
+
+// readResource reads a JSON resource and decodes it into out
+func (c *taskClient) readResource(ctx context.Context, uri string, out any) error {
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = uri
+
+	result, err := c.mcp.ReadResource(ctx, request)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	for _, contents := range result.Contents {
+		if text, ok := contents.(mcp.TextResourceContents); ok {
+			if err := json.Unmarshal([]byte(text.Text), out); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", uri, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s returned no JSON contents", uri)
+}
+
+// printJSON prints a response indented, as the server returned it
+func printJSON(value any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// resultText joins the text contents of a tool result
+func resultText(contents []mcp.Content) string {
+	var texts []string
+	for _, content := range contents {
+		if text, ok := mcp.AsTextContent(content); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
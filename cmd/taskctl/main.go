@@ -0,0 +1,120 @@
+// Command taskctl works the task queue from the terminal. It talks MCP over
+// HTTP to a running server, so it sees and may do exactly what the tools
+// allow the user whose token or API key it is given.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/internal/cli"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name        string
+	args        []string
+	description string
+	// setup registers the flags of the command and returns the function that
+	// runs it with the positional arguments
+	setup func(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error
+}
+
+// commands in the order of the usage text
+var commands = []command{
+	{name: "next", description: "Show the next task assigned to you, optionally waiting for one", setup: setupNext},
+	{name: "list", description: "List the tasks you created", setup: setupList},
+	{name: "show", args: []string{"TASK_ID"}, description: "Show a task with its comments", setup: setupShow},
+	{name: "create", args: []string{"DESCRIPTION"}, description: "Create a task for a user or a team", setup: setupCreate},
+	{name: "complete", args: []string{"TASK_ID"}, description: "Mark a task as completed", setup: setupComplete},
+	{name: "respond", args: []string{"TASK_ID", "COMMENT"}, description: "Answer a task that is waiting for you and send it back to its assignee", setup: setupRespond},
+}
+
+func main() {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		printUsage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n", name)
+		printUsage()
+		os.Exit(2)
+	}
+
+	// Parse command line flags; the connection flags default to the
+	// environment so that they need not be repeated
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	serverURL := flags.String("url", envOr("TASKCTL_URL", "http://localhost:8080/mcp"), "MCP endpoint of the server (env TASKCTL_URL)")
+	token := flags.String("token", os.Getenv("TASKCTL_TOKEN"), "JWT token to authenticate with (env TASKCTL_TOKEN)")
+	apiKey := flags.String("api-key", os.Getenv("TASKCTL_API_KEY"), "API key to authenticate with instead of a token (env TASKCTL_API_KEY)")
+	output := flags.String("output", envOr("TASKCTL_OUTPUT", "table"), "Output format: table or json (env TASKCTL_OUTPUT)")
+	run := cmd.setup(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", os.Args[0], cmd.name, strings.Join(cmd.args, " "), cmd.description)
+		flags.PrintDefaults()
+	}
+	params := cli.ParseInterspersed(flags, args)
+	if len(params) != len(cmd.args) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *output != "table" && *output != "json" {
+		fail(cmd.name, fmt.Errorf("invalid output format '%s', use table or json", *output))
+	}
+	if *token == "" && *apiKey == "" {
+		fail(cmd.name, fmt.Errorf("set -token or -api-key, or TASKCTL_TOKEN or TASKCTL_API_KEY"))
+	}
+
+	// Stop waiting on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := connect(ctx, *serverURL, *token, *apiKey, *output == "json")
+	if err != nil {
+		fail(cmd.name, err)
+	}
+	defer c.Close()
+
+	if err := run(ctx, c, params); err != nil {
+		fail(cmd.name, err)
+	}
+}
+
+// fail prints the error of a command and exits
+func fail(name string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	os.Exit(1)
+}
+
+// envOr returns the environment variable or the fallback if it is not set
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// findCommand looks up a command by name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// printUsage prints the list of commands
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", strings.TrimSpace(cmd.name+" "+strings.Join(cmd.args, " ")), cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dushes/simple-task-mcp/internal/cli"
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+// setupNext shows the next task assigned to the caller with get_next_task
+func setupNext(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	statusList := flags.String("status", "", "Comma-separated statuses to pick from (default: pending)")
+	wait := flags.Int("wait", 0, "Wait up to this many seconds for a task if there is none")
+
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		toolArgs := map[string]any{}
+		if statuses := splitList(*statusList); len(statuses) > 0 {
+			toolArgs["statuses"] = statuses
+		}
+		if *wait > 0 {
+			toolArgs["wait_seconds"] = *wait
+		}
+
+		var response models.NextTaskResponse
+		if err := c.callTool(ctx, "get_next_task", toolArgs, &response); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(response)
+		}
+		if response.Task == nil {
+			fmt.Println("No task")
+			return nil
+		}
+		return printTask(response.Task)
+	}
+}
+
+// setupList lists the tasks created by the caller, a user or a team with
+// list_created_tasks
+func setupList(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	statusList := flags.String("status", "", "Comma-separated statuses to list, e.g. waiting_for_user")
+	userName := flags.String("user", "", "List the tasks created by this user instead")
+	teamName := flags.String("team", "", "List the tasks of this team instead")
+	limit := flags.Int("limit", 0, "Maximum number of tasks to list (default: 50)")
+
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		toolArgs := map[string]any{}
+		if statuses := splitList(*statusList); len(statuses) > 0 {
+			toolArgs["statuses"] = statuses
+		}
+		if *userName != "" {
+			toolArgs["user_name"] = *userName
+		}
+		if *teamName != "" {
+			toolArgs["team_name"] = *teamName
+		}
+		if *limit > 0 {
+			toolArgs["limit"] = *limit
+		}
+
+		var response models.TaskListResponse
+		if err := c.callTool(ctx, "list_created_tasks", toolArgs, &response); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(response)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tASSIGNED TO\tTEAM\tCREATED AT\tDESCRIPTION")
+		for _, task := range response.Tasks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				task.ID, task.Status, task.AssignedTo, orDash(task.AssignedTeam), task.CreatedAt, cli.Summarize(task.Description))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nShowing %d of %d tasks\n", len(response.Tasks), response.TotalCount)
+		return nil
+	}
+}
+
+// setupShow shows a task with its comments from its task://{id} resource
+func setupShow(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		var task models.TaskWithUsers
+		if err := c.readResource(ctx, fmt.Sprintf("task://%s", args[0]), &task); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(task)
+		}
+		return printTask(&task)
+	}
+}
+
+// setupCreate creates a task with create_task
+func setupCreate(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	assignTo := flags.String("assign-to", "", "User to assign the task to")
+	teamName := flags.String("team", "", "Team to assign the task to; it goes to the member with the fewest open tasks")
+
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		if (*assignTo == "") == (*teamName == "") {
+			return fmt.Errorf("set either -assign-to or -team")
+		}
+
+		toolArgs := map[string]any{"description": args[0]}
+		if *assignTo != "" {
+			toolArgs["assigned_to"] = *assignTo
+		} else {
+			toolArgs["assigned_team"] = *teamName
+		}
+
+		var task models.TaskWithUsers
+		if err := c.callTool(ctx, "create_task", toolArgs, &task); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(task)
+		}
+		fmt.Printf("Task created for %s (ID: %s)\n", task.AssignedTo, task.ID)
+		return nil
+	}
+}
+
+// setupComplete completes a task with complete_task
+func setupComplete(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	result := flags.String("result", "", "Result or notes to store on the task")
+
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		toolArgs := map[string]any{"id": args[0]}
+		if *result != "" {
+			toolArgs["result"] = *result
+		}
+
+		var task models.TaskWithUsers
+		if err := c.callTool(ctx, "complete_task", toolArgs, &task); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(task)
+		}
+		fmt.Printf("Task completed: %s (ID: %s)\n", cli.Summarize(task.Description), task.ID)
+		return nil
+	}
+}
+
+// setupRespond answers a task waiting for user with respond_to_task
+func setupRespond(flags *flag.FlagSet) func(ctx context.Context, c *taskClient, args []string) error {
+	return func(ctx context.Context, c *taskClient, args []string) error {
+		var response models.TaskWithComment
+		if err := c.callTool(ctx, "respond_to_task", map[string]any{"id": args[0], "comment": args[1]}, &response); err != nil {
+			return err
+		}
+		if c.json {
+			return printJSON(response)
+		}
+		fmt.Printf("Responded to task, it is back with %s: %s (ID: %s)\n", response.AssignedTo, cli.Summarize(response.Description), response.ID)
+		return nil
+	}
+}
+
+// printTask prints the details of a task followed by its comments
+func printTask(task *models.TaskWithUsers) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", task.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", task.Status)
+	fmt.Fprintf(w, "Created by:\t%s\n", task.CreatedBy)
+	fmt.Fprintf(w, "Assigned to:\t%s\n", task.AssignedTo)
+	fmt.Fprintf(w, "Team:\t%s\n", orDash(task.AssignedTeam))
+	fmt.Fprintf(w, "Created at:\t%s\n", task.CreatedAt)
+	fmt.Fprintf(w, "Updated at:\t%s\n", task.UpdatedAt)
+	if task.Progress != nil {
+		fmt.Fprintf(w, "Progress:\t%d%% %s\n", task.Progress.Percent, task.Progress.Message)
+	}
+	if task.Result != nil {
+		fmt.Fprintf(w, "Result:\t%s\n", *task.Result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%s\n", task.Description)
+	if len(task.Comments) > 0 {
+		fmt.Println("\nComments:")
+		for _, comment := range task.Comments {
+			fmt.Printf("  [%s] %s: %s\n", comment.CreatedAt.Format("2006-01-02T15:04:05Z"), comment.CreatedByName, comment.Comment)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// orDash returns the value or "-" if it is not set
+func orDash(value *string) string {
+	if value == nil {
+		return "-"
+	}
+	return *value
+}
@@ -0,0 +1,567 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MigrationSet is the migrations of one database
+type MigrationSet struct {
+	// Dir is the directory of the set, relative to the root of the migrations
+	Dir string
+
+	// AdvisoryLock serializes the runs of concurrent processes with a
+	// PostgreSQL advisory lock
+	AdvisoryLock bool
+}
+
+// Migration sets
+var (
+	// PostgresMigrations holds the PostgreSQL migrations
+	PostgresMigrations = MigrationSet{Dir: ".", AdvisoryLock: true}
+
+	// SQLiteMigrations holds the SQLite migrations; a SQLite database serves a
+	// single server, so no lock is taken
+	SQLiteMigrations = MigrationSet{Dir: "sqlite"}
+)
+
+//go:embed migrations
+var embeddedMigrations embed.FS
+
+// migrationsDir replaces the embedded migrations when set
+var migrationsDir string
+
+// migrationLockID is the PostgreSQL advisory lock key that serializes
+// migration runs of servers starting at the same time
+const migrationLockID = 720394175
+
+// SetMigrationsDir makes migrations read from a directory laid out like
+// database/migrations instead of the ones embedded in the binary, e.g. to
+// try out a migration without rebuilding
+func SetMigrationsDir(dir string) {
+	migrationsDir = dir
+}
+
+// migrations returns the root of the migrations
+func migrations() (fs.FS, error) {
+	if migrationsDir != "" {
+		return os.DirFS(migrationsDir), nil
+	}
+	return fs.Sub(embeddedMigrations, "migrations")
+}
+
+// Migrator applies and rolls back the migrations of a set. A migration
+// NNN_name.sql is rolled back by its paired NNN_name.down.sql file.
+type Migrator struct {
+	db  *sql.DB
+	set MigrationSet
+}
+
+// NewMigrator creates a migrator for the migration set of the database
+func NewMigrator(db *sql.DB, set MigrationSet) *Migrator {
+	return &Migrator{db: db, set: set}
+}
+
+// MigrationStatus describes a migration file and whether it is applied
+type MigrationStatus struct {
+	Filename  string
+	Applied   bool
+	AppliedAt time.Time
+	// Changed is set when the file differs from the one that was applied
+	Changed bool
+	// Missing is set when an applied migration has no file anymore
+	Missing bool
+	// Reversible is set when the migration has a down file
+	Reversible bool
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// migrationSession is the state of a migration run
+type migrationSession struct {
+	conn    *sql.Conn
+	fsys    fs.FS
+	set     MigrationSet
+	files   []string
+	applied map[string]appliedMigration
+}
+
+// RunMigrations applies all pending migrations of the migration set
+func RunMigrations(ctx context.Context, db *sql.DB, set MigrationSet) error {
+	_, err := NewMigrator(db, set).Up(ctx, "", false)
+	return err
+}
+
+// Status lists the migrations in order with their state. It does not change
+// the database, which may not have been migrated at all.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.run(ctx, true, func(s *migrationSession) error {
+		for _, filename := range s.files {
+			status := MigrationStatus{Filename: filename, Reversible: s.hasDown(filename)}
+			if applied, ok := s.applied[filename]; ok {
+				content, err := s.read(filename)
+				if err != nil {
+					return err
+				}
+				status.Applied = true
+				status.AppliedAt = applied.appliedAt
+				status.Changed = applied.checksum != "" && applied.checksum != checksum(content)
+			}
+			statuses = append(statuses, status)
+		}
+
+		for filename, applied := range s.applied {
+			if !s.exists(filename) {
+				statuses = append(statuses, MigrationStatus{Filename: filename, Applied: true, AppliedAt: applied.appliedAt, Missing: true})
+			}
+		}
+
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Filename < statuses[j].Filename })
+		return nil
+	})
+	return statuses, err
+}
+
+// Up applies the pending migrations up to and including the target, or all
+// of them when the target is empty. The target is a migration filename with
+// or without .sql, or its number. It returns the applied migrations; with
+// dryRun they are only listed and the database is not changed.
+func (m *Migrator) Up(ctx context.Context, target string, dryRun bool) ([]string, error) {
+	var done []string
+	err := m.run(ctx, dryRun, func(s *migrationSession) error {
+		if len(s.files) == 0 {
+			log.Println("No migration files found")
+			return nil
+		}
+
+		files := s.files
+		if target != "" {
+			last := -1
+			for i, filename := range files {
+				if matchesTarget(filename, target) {
+					last = i
+				}
+			}
+			if last < 0 {
+				return fmt.Errorf("unknown migration '%s'", target)
+			}
+			files = files[:last+1]
+		}
+
+		log.Printf("Found %d migration files", len(s.files))
+		log.Printf("Already applied %d migrations", len(s.applied))
+
+		// Apply pending migrations
+		for _, filename := range files {
+			content, err := s.read(filename)
+			if err != nil {
+				return err
+			}
+			sum := checksum(content)
+
+			if applied, exists := s.applied[filename]; exists {
+				if err := s.verifyChecksum(ctx, filename, applied.checksum, sum, dryRun); err != nil {
+					return err
+				}
+				log.Printf("Migration %s already applied, skipping", filename)
+				continue
+			}
+
+			if dryRun {
+				log.Printf("Would apply migration: %s", filename)
+				done = append(done, filename)
+				continue
+			}
+
+			log.Printf("Applying migration: %s", filename)
+			start := time.Now()
+
+			if err := s.apply(ctx, filename, content, sum); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", filename, err)
+			}
+
+			duration := time.Since(start)
+			log.Printf("Successfully applied migration %s (took %v)", filename, duration)
+			done = append(done, filename)
+		}
+
+		if len(done) == 0 {
+			log.Println("All migrations are up to date")
+		} else if !dryRun {
+			log.Printf("Successfully applied %d new migrations", len(done))
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the given number of most recently numbered applied
+// migrations with their down files. It returns the rolled back migrations;
+// with dryRun they are only listed and the database is not changed.
+func (m *Migrator) Down(ctx context.Context, steps int, dryRun bool) ([]string, error) {
+	var done []string
+	err := m.run(ctx, dryRun, func(s *migrationSession) error {
+		var applied []string
+		for filename := range s.applied {
+			applied = append(applied, filename)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(applied)))
+		if steps < len(applied) {
+			applied = applied[:steps]
+		}
+
+		// Check every down file first so that a missing one stops the run
+		// before anything is rolled back
+		for _, filename := range applied {
+			if !s.hasDown(filename) {
+				return fmt.Errorf("migration %s cannot be rolled back, %s does not exist", filename, downFilename(filename))
+			}
+		}
+
+		for _, filename := range applied {
+			if dryRun {
+				log.Printf("Would roll back migration: %s", filename)
+				done = append(done, filename)
+				continue
+			}
+
+			log.Printf("Rolling back migration: %s", filename)
+			if err := s.rollBack(ctx, filename); err != nil {
+				return fmt.Errorf("failed to roll back migration %s: %w", filename, err)
+			}
+			done = append(done, filename)
+		}
+
+		if len(done) == 0 {
+			log.Println("No applied migrations to roll back")
+		}
+		return nil
+	})
+	return done, err
+}
+
+// run starts a migration session and calls fn with it. A read-only session
+// neither takes the lock nor creates or upgrades the tracking table.
+func (m *Migrator) run(ctx context.Context, readOnly bool, fn func(s *migrationSession) error) error {
+	fsys, err := migrations()
+	if err != nil {
+		return fmt.Errorf("failed to open migrations: %w", err)
+	}
+	if migrationsDir != "" {
+		log.Printf("Reading migrations from %s", migrationsDir)
+	}
+
+	// The whole run uses one connection: advisory locks belong to a session,
+	// and SQLite stores have no second connection to spare
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.set.AdvisoryLock && !readOnly {
+		log.Println("Waiting for migration lock...")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+	}
+
+	// Create migrations tracking table
+	if !readOnly {
+		if err := createMigrationsTable(ctx, conn); err != nil {
+			return fmt.Errorf("failed to create migrations table: %w", err)
+		}
+	}
+
+	// Get migration files
+	files, err := getMigrationFiles(fsys, m.set.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to get migration files: %w", err)
+	}
+
+	// Get already applied migrations
+	applied, err := getAppliedMigrations(ctx, conn, readOnly)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	return fn(&migrationSession{conn: conn, fsys: fsys, set: m.set, files: files, applied: applied})
+}
+
+// createMigrationsTable creates the table for tracking applied migrations, and
+// adds the checksum column to tables created before it existed
+func createMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	query := `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		filename VARCHAR(255) PRIMARY KEY,
+		applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+		checksum VARCHAR(64)
+	);`
+
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	// SQLite has no ADD COLUMN IF NOT EXISTS, so probe for the column
+	var count int
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(checksum) FROM schema_migrations").Scan(&count)
+	if err != nil {
+		if _, err := conn.ExecContext(ctx, "ALTER TABLE schema_migrations ADD COLUMN checksum VARCHAR(64)"); err != nil {
+			return err
+		}
+	}
+
+	log.Println("Migrations tracking table ready")
+	return nil
+}
+
+// getMigrationFiles returns sorted list of migration files in the directory;
+// down files and the subdirectories that hold the migration sets of other
+// databases are skipped
+func getMigrationFiles(fsys fs.FS, dir string) ([]string, error) {
+	var files []string
+
+	// Entries are sorted by filename
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".sql") && !strings.HasSuffix(name, ".down.sql") {
+			files = append(files, name)
+		}
+	}
+
+	return files, nil
+}
+
+// getAppliedMigrations returns the applied migrations by filename; the
+// checksum is empty for migrations applied before checksums were recorded.
+// Read-only sessions also accept a tracking table that was never created or
+// has no checksum column yet.
+func getAppliedMigrations(ctx context.Context, conn *sql.Conn, readOnly bool) (map[string]appliedMigration, error) {
+	applied := make(map[string]appliedMigration)
+
+	query := "SELECT filename, applied_at, checksum FROM schema_migrations"
+	if readOnly {
+		columns, err := trackingColumns(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			return applied, nil
+		}
+		if !columns["checksum"] {
+			query = "SELECT filename, applied_at, NULL FROM schema_migrations"
+		}
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var filename string
+		var appliedAt appliedTime
+		var sum sql.NullString
+		if err := rows.Scan(&filename, &appliedAt, &sum); err != nil {
+			return nil, err
+		}
+		applied[filename] = appliedMigration{checksum: sum.String, appliedAt: time.Time(appliedAt)}
+	}
+
+	return applied, rows.Err()
+}
+
+// trackingColumns returns the columns of schema_migrations, or nil if the
+// table does not exist. The probe works the same on PostgreSQL and SQLite.
+func trackingColumns(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	// Check the connection first, so that a failing probe means that the
+	// table is missing
+	if err := conn.PingContext(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT * FROM schema_migrations LIMIT 0")
+	if err != nil {
+		return nil, nil
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[strings.ToLower(name)] = true
+	}
+	return columns, nil
+}
+
+// appliedTime scans schema_migrations.applied_at, which SQLite returns as
+// text in the format of CURRENT_TIMESTAMP
+type appliedTime time.Time
+
+// Scan implements sql.Scanner
+func (t *appliedTime) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = appliedTime{}
+	case time.Time:
+		*t = appliedTime(v)
+	case string:
+		parsed, err := time.Parse("2006-01-02 15:04:05", v)
+		if err != nil {
+			return err
+		}
+		*t = appliedTime(parsed)
+	default:
+		return fmt.Errorf("unsupported applied_at value %T", value)
+	}
+	return nil
+}
+
+// downFilename returns the name of the down file of a migration
+func downFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".sql") + ".down.sql"
+}
+
+// matchesTarget reports whether the migration is selected by an up target;
+// numbers match with or without leading zeros
+func matchesTarget(filename, target string) bool {
+	name := strings.TrimSuffix(filename, ".sql")
+	if filename == target || name == target {
+		return true
+	}
+
+	prefix, _, _ := strings.Cut(name, "_")
+	number, err := strconv.Atoi(prefix)
+	if err != nil {
+		return false
+	}
+	targetNumber, err := strconv.Atoi(target)
+	return err == nil && number == targetNumber
+}
+
+// checksum returns the hex encoded SHA-256 of a migration file
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// read reads a file of the migration set
+func (s *migrationSession) read(filename string) ([]byte, error) {
+	content, err := fs.ReadFile(s.fsys, path.Join(s.set.Dir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration file %s: %w", filename, err)
+	}
+	return content, nil
+}
+
+// exists reports whether the migration set has the file
+func (s *migrationSession) exists(filename string) bool {
+	_, err := fs.Stat(s.fsys, path.Join(s.set.Dir, filename))
+	return err == nil
+}
+
+// hasDown reports whether the migration has a down file
+func (s *migrationSession) hasDown(filename string) bool {
+	return s.exists(downFilename(filename))
+}
+
+// verifyChecksum fails if an applied migration file was edited afterwards;
+// migrations applied before checksums were recorded adopt the current one
+func (s *migrationSession) verifyChecksum(ctx context.Context, filename, applied, sum string, dryRun bool) error {
+	if applied == "" {
+		if dryRun {
+			return nil
+		}
+		_, err := s.conn.ExecContext(ctx, "UPDATE schema_migrations SET checksum = $1 WHERE filename = $2", sum, filename)
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of migration %s: %w", filename, err)
+		}
+		return nil
+	}
+
+	if applied != sum {
+		return fmt.Errorf("migration %s was changed after it was applied (checksum %s, applied %s); add a new migration instead", filename, sum, applied)
+	}
+	return nil
+}
+
+// apply executes a migration and records it in one transaction
+func (s *migrationSession) apply(ctx context.Context, filename string, content []byte, sum string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	log.Printf("Executing migration SQL from %s", filename)
+
+	// Execute migration
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return fmt.Errorf("failed to execute migration SQL: %w", err)
+	}
+
+	// Record migration as applied
+	_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (filename, checksum) VALUES ($1, $2)", filename, sum)
+	if err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// rollBack executes the down file of a migration and forgets the migration in
+// one transaction
+func (s *migrationSession) rollBack(ctx context.Context, filename string) error {
+	content, err := s.read(downFilename(filename))
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return fmt.Errorf("failed to execute down migration SQL: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE filename = $1", filename)
+	if err != nil {
+		return fmt.Errorf("failed to forget migration: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return errors.New("migration is not recorded as applied")
+	}
+
+	return tx.Commit()
+}
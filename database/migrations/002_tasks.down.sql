@@ -0,0 +1,2 @@
+-- Drop tasks table with its indexes
+DROP TABLE IF EXISTS tasks;
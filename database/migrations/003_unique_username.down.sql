@@ -0,0 +1,3 @@
+-- Drop unique constraint and index on users.name
+DROP INDEX IF EXISTS idx_users_name;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_name_unique;
@@ -0,0 +1,2 @@
+-- Drop result field from tasks table
+ALTER TABLE tasks DROP COLUMN IF EXISTS result;
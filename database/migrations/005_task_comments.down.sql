@@ -0,0 +1,2 @@
+-- Drop task_comments table with its indexes
+DROP TABLE IF EXISTS task_comments;
@@ -0,0 +1,2 @@
+-- Drop user description field
+ALTER TABLE users DROP COLUMN IF EXISTS description;
@@ -0,0 +1,2 @@
+-- Drop api_keys table with its index
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,2 @@
+-- Drop user_identities table with its index
+DROP TABLE IF EXISTS user_identities;
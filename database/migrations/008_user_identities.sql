@@ -0,0 +1,12 @@
+-- Create user_identities table linking external identity provider subjects to users
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_identities_issuer_subject_unique UNIQUE (issuer, subject)
+);
+
+-- Create index for looking up identities by user
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
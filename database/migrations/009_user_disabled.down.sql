@@ -0,0 +1,3 @@
+-- Drop disabled flag from users
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_disabled;
@@ -0,0 +1,2 @@
+-- Drop user_roles table; users fall back to the default 'agent' role
+DROP TABLE IF EXISTS user_roles;
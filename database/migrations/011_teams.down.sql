@@ -0,0 +1,4 @@
+-- Drop team assignment of tasks, then the team tables
+ALTER TABLE tasks DROP COLUMN IF EXISTS assigned_team_id;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
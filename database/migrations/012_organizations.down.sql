@@ -0,0 +1,17 @@
+-- Drop organizations; usernames and team names become globally unique again,
+-- which fails if two organizations use the same name
+DROP INDEX IF EXISTS idx_users_org_id;
+DROP INDEX IF EXISTS idx_teams_org_id;
+DROP INDEX IF EXISTS idx_tasks_org_id;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS org_id;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_org_name_unique;
+ALTER TABLE teams DROP COLUMN IF EXISTS org_id;
+ALTER TABLE teams ADD CONSTRAINT teams_name_unique UNIQUE (name);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_org_name_unique;
+ALTER TABLE users DROP COLUMN IF EXISTS org_id;
+ALTER TABLE users ADD CONSTRAINT users_name_unique UNIQUE (name);
+
+DROP TABLE IF EXISTS organizations;
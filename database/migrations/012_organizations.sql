@@ -0,0 +1,34 @@
+-- Create organizations table; users, teams and tasks belong to exactly one organization
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT organizations_name_unique UNIQUE (name)
+);
+
+-- Existing data moves to the default organization
+INSERT INTO organizations (name) VALUES ('default') ON CONFLICT DO NOTHING;
+
+-- Users: usernames are unique per organization instead of globally
+ALTER TABLE users ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE users SET org_id = (SELECT id FROM organizations WHERE name = 'default') WHERE org_id IS NULL;
+ALTER TABLE users ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_name_unique;
+ALTER TABLE users ADD CONSTRAINT users_org_name_unique UNIQUE (org_id, name);
+
+-- Teams: team names are unique per organization
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE teams SET org_id = (SELECT id FROM organizations WHERE name = 'default') WHERE org_id IS NULL;
+ALTER TABLE teams ALTER COLUMN org_id SET NOT NULL;
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_name_unique;
+ALTER TABLE teams ADD CONSTRAINT teams_org_name_unique UNIQUE (org_id, name);
+
+-- Tasks: the organization of the creator
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS org_id UUID REFERENCES organizations(id) ON DELETE CASCADE;
+UPDATE tasks SET org_id = (SELECT org_id FROM users WHERE users.id = tasks.created_by) WHERE org_id IS NULL;
+ALTER TABLE tasks ALTER COLUMN org_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_org_id ON users(org_id);
+CREATE INDEX IF NOT EXISTS idx_teams_org_id ON teams(org_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_org_id ON tasks(org_id) WHERE NOT is_archived;
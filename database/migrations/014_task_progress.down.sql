@@ -0,0 +1,5 @@
+-- Drop progress history and the latest progress of tasks
+DROP TABLE IF EXISTS task_progress;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_updated_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_message;
+ALTER TABLE tasks DROP COLUMN IF EXISTS progress_percent;
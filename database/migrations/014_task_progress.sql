@@ -0,0 +1,16 @@
+-- Progress reported by the assignee: the latest report is kept on the task,
+-- every report is kept in task_progress
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_percent INTEGER CHECK (progress_percent BETWEEN 0 AND 100);
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_message TEXT;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS progress_updated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS task_progress (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    percent INTEGER NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    message TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_progress_task_id ON task_progress(task_id);
@@ -0,0 +1,2 @@
+-- Drop token generation from users
+ALTER TABLE users DROP COLUMN IF EXISTS token_generation;
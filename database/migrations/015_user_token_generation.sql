@@ -0,0 +1,4 @@
+-- Tokens carry the token generation of their user when issued; revoking the
+-- tokens of a user increments it, so that tokens of older generations are
+-- rejected
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_generation INTEGER NOT NULL DEFAULT 0;
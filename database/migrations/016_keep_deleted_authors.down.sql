@@ -0,0 +1,5 @@
+-- Require an author again; entries of deleted users have none and are removed
+DELETE FROM task_comments WHERE created_by IS NULL;
+DELETE FROM task_progress WHERE created_by IS NULL;
+ALTER TABLE task_comments ALTER COLUMN created_by SET NOT NULL;
+ALTER TABLE task_progress ALTER COLUMN created_by SET NOT NULL;
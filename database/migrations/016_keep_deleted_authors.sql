@@ -0,0 +1,4 @@
+-- Comments and progress reports outlive their author: deleting a user sets
+-- created_by to NULL instead of crediting the entries to someone else
+ALTER TABLE task_comments ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE task_progress ALTER COLUMN created_by DROP NOT NULL;
@@ -0,0 +1,11 @@
+-- Drop the whole schema, dependent tables first
+DROP TABLE IF EXISTS task_progress;
+DROP TABLE IF EXISTS task_comments;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,2 @@
+-- Drop token generation from users
+ALTER TABLE users DROP COLUMN token_generation;
@@ -0,0 +1,30 @@
+-- Comments and progress reports outlive their author: deleting a user sets
+-- created_by to NULL instead of crediting the entries to someone else.
+-- SQLite cannot drop NOT NULL from a column, so both tables are rebuilt.
+CREATE TABLE task_comments_new (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by TEXT REFERENCES users(id),
+    comment TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+INSERT INTO task_comments_new (id, task_id, created_by, comment, created_at)
+    SELECT id, task_id, created_by, comment, created_at FROM task_comments;
+DROP TABLE task_comments;
+ALTER TABLE task_comments_new RENAME TO task_comments;
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_comments_created_at ON task_comments(created_at);
+
+CREATE TABLE task_progress_new (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by TEXT REFERENCES users(id),
+    percent INTEGER NOT NULL CHECK (percent BETWEEN 0 AND 100),
+    message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+INSERT INTO task_progress_new (id, task_id, created_by, percent, message, created_at)
+    SELECT id, task_id, created_by, percent, message, created_at FROM task_progress;
+DROP TABLE task_progress;
+ALTER TABLE task_progress_new RENAME TO task_progress;
+CREATE INDEX IF NOT EXISTS idx_task_progress_task_id ON task_progress(task_id);
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB opens an empty SQLite database
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// tables returns the tables of a SQLite database
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestReadOnlyOnEmptyDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrator := NewMigrator(db, SQLiteMigrations)
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) == 0 {
+		t.Fatal("Status listed no migrations")
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("%s is applied in an empty database", status.Filename)
+		}
+	}
+
+	pending, err := migrator.Up(ctx, "", true)
+	if err != nil {
+		t.Fatalf("Up dry run: %v", err)
+	}
+	if len(pending) != len(statuses) {
+		t.Errorf("Up dry run listed %d migrations, want %d", len(pending), len(statuses))
+	}
+
+	if _, err := migrator.Down(ctx, 1, true); err != nil {
+		t.Fatalf("Down dry run: %v", err)
+	}
+
+	if names := tables(t, db); len(names) != 0 {
+		t.Errorf("read-only calls created tables %v", names)
+	}
+}
+
+func TestReadOnlyOnLegacyTrackingTable(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrator := NewMigrator(db, SQLiteMigrations)
+
+	if _, err := migrator.Up(ctx, "", false); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	// Recreate the tracking table as it was before checksums were recorded
+	for _, query := range []string{
+		"CREATE TABLE legacy_migrations (filename VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP)",
+		"INSERT INTO legacy_migrations (filename, applied_at) SELECT filename, applied_at FROM schema_migrations",
+		"DROP TABLE schema_migrations",
+		"ALTER TABLE legacy_migrations RENAME TO schema_migrations",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Changed {
+			t.Errorf("%s: applied = %v, changed = %v, want applied and unchanged", status.Filename, status.Applied, status.Changed)
+		}
+	}
+	if _, err := migrator.Up(ctx, "", true); err != nil {
+		t.Fatalf("Up dry run: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('schema_migrations') WHERE name = 'checksum'").Scan(&count); err != nil {
+		t.Fatalf("table info: %v", err)
+	}
+	if count != 0 {
+		t.Error("read-only calls added the checksum column")
+	}
+
+	// A real run upgrades the table
+	if _, err := migrator.Up(ctx, "", false); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE checksum IS NOT NULL").Scan(&count); err != nil {
+		t.Fatalf("count checksums: %v", err)
+	}
+	if count != len(statuses) {
+		t.Errorf("%d checksums recorded, want %d", count, len(statuses))
+	}
+}
@@ -0,0 +1,35 @@
+// Package cli holds helpers shared by the command-line tools
+package cli
+
+import (
+	"flag"
+	"strings"
+)
+
+// ParseInterspersed parses flags that come before, between or after the
+// positional arguments and returns the positional arguments. Everything after
+// "--" is positional. The flag set should exit on errors.
+func ParseInterspersed(flags *flag.FlagSet, args []string) []string {
+	var params []string
+	for {
+		flags.Parse(args)
+		rest := flags.Args()
+		if len(rest) == 0 {
+			return params
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(params, rest...)
+		}
+		params = append(params, rest[0])
+		args = rest[1:]
+	}
+}
+
+// Summarize shortens a task description to its first line for tables
+func Summarize(description string) string {
+	line, _, _ := strings.Cut(description, "\n")
+	if runes := []rune(line); len(runes) > 60 {
+		return string(runes[:57]) + "..."
+	}
+	return line
+}
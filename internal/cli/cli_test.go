@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		params []string
+		dryRun bool
+		limit  int
+	}{
+		{"no arguments", nil, nil, false, 0},
+		{"flags first", []string{"-dry-run", "down", "3"}, []string{"down", "3"}, true, 0},
+		{"flags last", []string{"down", "3", "--dry-run"}, []string{"down", "3"}, true, 0},
+		{"flags between", []string{"down", "-limit", "5", "3"}, []string{"down", "3"}, false, 5},
+		{"after double dash", []string{"show", "--", "-dry-run", "x"}, []string{"show", "-dry-run", "x"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			dryRun := flags.Bool("dry-run", false, "")
+			limit := flags.Int("limit", 0, "")
+
+			params := ParseInterspersed(flags, tt.args)
+			if !reflect.DeepEqual(params, tt.params) {
+				t.Errorf("params = %q, want %q", params, tt.params)
+			}
+			if *dryRun != tt.dryRun {
+				t.Errorf("dry-run = %v, want %v", *dryRun, tt.dryRun)
+			}
+			if *limit != tt.limit {
+				t.Errorf("limit = %d, want %d", *limit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	long := strings.Repeat("é", 70)
+	tests := map[string]string{
+		"short":              "short",
+		"first line\nsecond": "first line",
+		long:                 strings.Repeat("é", 57) + "...",
+	}
+	for description, want := range tests {
+		if got := Summarize(description); got != want {
+			t.Errorf("Summarize(%q) = %q, want %q", description, got, want)
+		}
+	}
+}
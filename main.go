@@ -11,19 +11,33 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/dushes/simple-task-mcp/api"
 	"github.com/dushes/simple-task-mcp/auth"
 	"github.com/dushes/simple-task-mcp/config"
 	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/models"
 	middleware "github.com/dushes/simple-task-mcp/server"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/backend"
 	"github.com/dushes/simple-task-mcp/tools"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 func main() {
+	// Migrations can also be managed without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Parse command line flags
-	var transport string
+	var transport, token, migrationsDir string
+	var skipMigrations bool
 	flag.StringVar(&transport, "t", "http", "Transport type (stdio or http)")
 	flag.StringVar(&transport, "transport", "http", "Transport type (stdio or http)")
+	flag.StringVar(&token, "token", "", "JWT token used to authenticate stdio sessions (overrides MCP_AUTH_TOKEN)")
+	flag.StringVar(&migrationsDir, "migrations-dir", "", "Read migrations from this directory instead of the ones built into the binary")
+	flag.BoolVar(&skipMigrations, "skip-migrations", false, "Do not apply pending migrations at startup; apply them with the migrate command instead")
 	flag.Parse()
 
 	// Load configuration
@@ -31,46 +45,110 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	if token != "" {
+		cfg.AuthToken = token
+	}
 
 	// Configure logging
 	configureLogging(cfg.LogLevel)
 
-	// Connect to database
-	if err := database.Connect(cfg.DatabaseURL); err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	// Open the store selected by DATABASE_URL; it also watches task changes
+	// so that get_next_task can wait for new tasks
+	st, err := backend.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
 	}
-	defer database.Close()
+	defer st.Close()
 
 	// Run migrations
-	if err := database.RunMigrations(); err != nil {
+	database.SetMigrationsDir(migrationsDir)
+	if skipMigrations {
+		warnPendingMigrations(context.Background(), st)
+	} else if err := st.Migrate(context.Background()); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
 	// Create JWT manager
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret)
 
+	// The in-memory store starts empty, so seed an admin to log in with
+	if backend.IsMemory(cfg.DatabaseURL) {
+		adminToken, err := seedMemoryAdmin(context.Background(), st, jwtManager)
+		if err != nil {
+			log.Fatalf("Failed to seed admin user: %v", err)
+		}
+		log.Printf("Using in-memory store, data is lost on shutdown. Admin token:\n%s", adminToken)
+		if cfg.AuthToken == "" {
+			cfg.AuthToken = adminToken
+		}
+	}
+
 	// Create MCP server
-	mcpServer, err := createMCPServer()
+	mcpServer, err := createMCPServer(jwtManager, st)
 	if err != nil {
 		log.Fatalf("Failed to create MCP server: %v", err)
 	}
 
 	// Register tools
-	if err := registerTools(mcpServer, jwtManager); err != nil {
+	if err := registerTools(mcpServer, jwtManager, st); err != nil {
 		log.Fatalf("Failed to register tools: %v", err)
 	}
 
+	// Require confirmation for the configured destructive tools
+	if err := tools.RequireConfirmation(cfg.RequireConfirmation); err != nil {
+		log.Fatalf("Invalid MCP_REQUIRE_CONFIRMATION: %v", err)
+	}
+
+	// Register resources
+	if err := registerResources(mcpServer, jwtManager, st); err != nil {
+		log.Fatalf("Failed to register resources: %v", err)
+	}
+
+	// Register prompts
+	if err := registerPrompts(mcpServer, jwtManager, st); err != nil {
+		log.Fatalf("Failed to register prompts: %v", err)
+	}
+
 	// Start server with selected transport
 	if transport == "http" {
-		// Create HTTP server with SSE support
-		streamableServer := server.NewStreamableHTTPServer(mcpServer,
-			server.WithStateLess(true), // Make server stateless for easier testing
-		)
+		// Create HTTP server with SSE support. Stateful sessions keep the
+		// caller's credential and a GET stream for server-initiated messages.
+		httpOptions := []server.StreamableHTTPOption{server.WithStateLess(true)}
+		if cfg.HTTPStateful {
+			httpOptions = []server.StreamableHTTPOption{
+				server.WithStateful(true),
+				server.WithSessionIdleTTL(time.Duration(cfg.HTTPSessionIdleMinutes) * time.Minute),
+				server.WithHeartbeatInterval(30 * time.Second),
+			}
+		}
+		streamableServer := server.NewStreamableHTTPServer(mcpServer, httpOptions...)
 
 		// Create HTTP mux with CORS middleware
 		mux := http.NewServeMux()
 		mux.Handle("/mcp", middleware.CORSMiddleware(streamableServer))
 
+		// Mount the REST API, which runs the same tools and resources
+		mux.Handle(api.Prefix, middleware.CORSMiddleware(api.NewHandler(mcpServer, jwtManager, st)))
+
+		// Mount OIDC login endpoints when an identity provider is configured
+		if cfg.OIDCIssuerURL != "" {
+			oidcHandler, err := middleware.NewOIDCHandler(context.Background(), middleware.OIDCConfig{
+				IssuerURL:     cfg.OIDCIssuerURL,
+				ClientID:      cfg.OIDCClientID,
+				ClientSecret:  cfg.OIDCClientSecret,
+				RedirectURL:   cfg.OIDCRedirectURL,
+				UsernameClaim: cfg.OIDCUsernameClaim,
+				AutoProvision: cfg.OIDCAutoProvision,
+				LinkByName:    cfg.OIDCLinkByName,
+				Organization:  cfg.OIDCOrganization,
+			}, jwtManager, st)
+			if err != nil {
+				log.Fatalf("Failed to configure OIDC login: %v", err)
+			}
+			mux.HandleFunc("/auth/login", oidcHandler.Login)
+			mux.HandleFunc("/auth/callback", oidcHandler.Callback)
+		}
+
 		// Create custom HTTP server
 		httpServer := &http.Server{
 			Addr:    fmt.Sprintf(":%d", cfg.MCPServerPort),
@@ -85,7 +163,14 @@ func main() {
 		go func() {
 			log.Printf("Starting MCP HTTP server on port %d", cfg.MCPServerPort)
 			log.Printf("Endpoint: http://localhost:%d/mcp", cfg.MCPServerPort)
+			log.Printf("REST API: http://localhost:%d%s (spec: %sopenapi.yaml)", cfg.MCPServerPort, api.Prefix, api.Prefix)
 			log.Println("CORS enabled for cross-origin requests")
+			if cfg.HTTPStateful {
+				log.Printf("Stateful sessions enabled (idle timeout: %d minutes)", cfg.HTTPSessionIdleMinutes)
+			}
+			if cfg.OIDCIssuerURL != "" {
+				log.Printf("OIDC login: http://localhost:%d/auth/login", cfg.MCPServerPort)
+			}
 			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 				log.Fatalf("Server error: %v", err)
 			}
@@ -106,83 +191,221 @@ func main() {
 
 		log.Println("Server stopped")
 	} else {
+		// Bind the configured token to the stdio session, since stdio has no headers
+		if cfg.AuthToken == "" {
+			log.Println("Warning: no token configured for stdio transport, tool calls will be rejected (use --token or MCP_AUTH_TOKEN)")
+		} else if auth.IsAPIKey(cfg.AuthToken) {
+			log.Println("Stdio session authenticated with an API key")
+		} else if _, err := jwtManager.ValidateToken(cfg.AuthToken); err != nil {
+			log.Fatalf("Invalid stdio token: %v", err)
+		}
+
 		// Start stdio server
 		log.Println("Starting MCP server with stdio transport")
-		if err := server.ServeStdio(mcpServer); err != nil {
+		err := server.ServeStdio(mcpServer, server.WithStdioContextFunc(func(ctx context.Context) context.Context {
+			return auth.WithToken(ctx, cfg.AuthToken)
+		}))
+		if err != nil {
 			log.Fatalf("Server error: %v", err)
 		}
 	}
 }
 
+// seedMemoryAdmin creates the default organization and an admin user in a
+// fresh in-memory store and returns a token for the admin
+func seedMemoryAdmin(ctx context.Context, st store.Store, jwtManager *auth.JWTManager) (string, error) {
+	org, err := st.EnsureOrganization(ctx, "default")
+	if err != nil {
+		return "", err
+	}
+
+	admin := models.User{OrgID: org.ID, Name: "admin", IsAdmin: true}
+	if err := st.CreateUser(ctx, &admin, nil); err != nil {
+		return "", err
+	}
+
+	return jwtManager.GenerateToken(admin.ID, org.ID, true, admin.TokenGeneration)
+}
+
 // createMCPServer creates and configures the MCP server
-func createMCPServer() (*server.MCPServer, error) {
+func createMCPServer(jwtManager *auth.JWTManager, st store.Store) (*server.MCPServer, error) {
 	mcpServer := server.NewMCPServer(
 		"simple-task-mcp",
 		"0.1.0",
 		server.WithPromptCapabilities(false),
-		server.WithResourceCapabilities(false, false),
+		server.WithResourceCapabilities(true, false),
 		server.WithToolCapabilities(true),
+		server.WithElicitation(),
+		server.WithToolHandlerMiddleware(tools.AuthMiddleware(jwtManager, st)),
+		server.WithHooks(tools.Hooks(jwtManager, st)),
 	)
 
 	return mcpServer, nil
 }
 
 // registerTools registers all available tools with the server
-func registerTools(mcpServer *server.MCPServer, jwtManager *auth.JWTManager) error {
+func registerTools(mcpServer *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	// Register create_user tool (admin only)
-	if err := tools.RegisterCreateUserTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterCreateUserTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register create_user tool: %w", err)
 	}
 
+	// Register update_user tool (admin only)
+	if err := tools.RegisterUpdateUserTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register update_user tool: %w", err)
+	}
+
+	// Register disable_user tool (admin only)
+	if err := tools.RegisterDisableUserTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register disable_user tool: %w", err)
+	}
+
+	// Register delete_user tool (admin only)
+	if err := tools.RegisterDeleteUserTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register delete_user tool: %w", err)
+	}
+
+	// Register set_user_roles tool (admin only)
+	if err := tools.RegisterSetUserRolesTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register set_user_roles tool: %w", err)
+	}
+
 	// Register create_task tool
-	if err := tools.RegisterCreateTaskTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterCreateTaskTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register create_task tool: %w", err)
 	}
 
 	// Register list_created_tasks tool
-	if err := tools.RegisterListCreatedTasksTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterListCreatedTasksTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register list_created_tasks tool: %w", err)
 	}
 
 	// Register get_next_task tool
-	if err := tools.RegisterGetNextTaskTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterGetNextTaskTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register get_next_task tool: %w", err)
 	}
 
 	// Register complete_task tool
-	if err := tools.RegisterCompleteTaskTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterCompleteTaskTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register complete_task tool: %w", err)
 	}
 
 	// Register cancel_task tool
-	if err := tools.RegisterCancelTaskTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterCancelTaskTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register cancel_task tool: %w", err)
 	}
 
 	// Register wait_for_user tool
-	if err := tools.RegisterWaitForUserTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterWaitForUserTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register wait_for_user tool: %w", err)
 	}
 
+	// Register respond_to_task tool
+	if err := tools.RegisterRespondToTaskTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register respond_to_task tool: %w", err)
+	}
+
+	// Register report_progress tool
+	if err := tools.RegisterReportProgressTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register report_progress tool: %w", err)
+	}
+
 	// Register generate_token tool (admin only)
-	if err := tools.RegisterGenerateTokenTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterGenerateTokenTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register generate_token tool: %w", err)
 	}
 
 	// Register get_token_info tool
-	if err := tools.RegisterGetTokenInfoTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterGetTokenInfoTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register get_token_info tool: %w", err)
 	}
 
 	// Register list_users tool
-	if err := tools.RegisterListUsersTool(mcpServer, jwtManager); err != nil {
+	if err := tools.RegisterListUsersTool(mcpServer, jwtManager, st); err != nil {
 		return fmt.Errorf("failed to register list_users tool: %w", err)
 	}
 
+	// Register create_api_key tool
+	if err := tools.RegisterCreateAPIKeyTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register create_api_key tool: %w", err)
+	}
+
+	// Register revoke_api_key tool
+	if err := tools.RegisterRevokeAPIKeyTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register revoke_api_key tool: %w", err)
+	}
+
+	// Register create_team tool (admin only)
+	if err := tools.RegisterCreateTeamTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register create_team tool: %w", err)
+	}
+
+	// Register add_team_member tool (admin only)
+	if err := tools.RegisterAddTeamMemberTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register add_team_member tool: %w", err)
+	}
+
+	// Register remove_team_member tool (admin only)
+	if err := tools.RegisterRemoveTeamMemberTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register remove_team_member tool: %w", err)
+	}
+
+	// Register list_teams tool
+	if err := tools.RegisterListTeamsTool(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register list_teams tool: %w", err)
+	}
+
 	log.Println("All tools registered successfully")
 	return nil
 }
 
+// registerResources registers all available resource templates with the server
+func registerResources(mcpServer *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	// Register task://{id} resource
+	if err := tools.RegisterTaskResource(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register task resource: %w", err)
+	}
+
+	// Register task://{id}/comments resource
+	if err := tools.RegisterTaskCommentsResource(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register task comments resource: %w", err)
+	}
+
+	// Register task://{id}/progress resource
+	if err := tools.RegisterTaskProgressResource(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register task progress resource: %w", err)
+	}
+
+	// Register user://{name}/inbox resource
+	if err := tools.RegisterUserInboxResource(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register user inbox resource: %w", err)
+	}
+
+	log.Println("All resources registered successfully")
+	return nil
+}
+
+// registerPrompts registers all available prompts with the server
+func registerPrompts(mcpServer *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	// Register work_on_next_task prompt
+	if err := tools.RegisterWorkOnNextTaskPrompt(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register work_on_next_task prompt: %w", err)
+	}
+
+	// Register triage_created_tasks prompt
+	if err := tools.RegisterTriageCreatedTasksPrompt(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register triage_created_tasks prompt: %w", err)
+	}
+
+	// Register summarize_task_history prompt
+	if err := tools.RegisterSummarizeTaskHistoryPrompt(mcpServer, jwtManager, st); err != nil {
+		return fmt.Errorf("failed to register summarize_task_history prompt: %w", err)
+	}
+
+	log.Println("All prompts registered successfully")
+	return nil
+}
+
 // configureLogging sets up logging based on the log level
 func configureLogging(level string) {
 	// For simplicity, we'll just use standard log package
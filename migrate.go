@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/dushes/simple-task-mcp/config"
+	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/internal/cli"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/backend"
+)
+
+const migrateUsage = `Usage: %s migrate <command> [arguments] [flags]
+
+Commands:
+  status         List the migrations and whether they are applied
+  up [target]    Apply the pending migrations, up to and including target
+                 (a migration number or filename) when given
+  down [steps]   Roll back the last applied migrations with their .down.sql
+                 files (default 1)
+
+Flags:
+`
+
+// runMigrate runs the migrate subcommand and exits on failure
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "List the migrations that would be applied or rolled back without changing the database")
+	migrationsDir := flags.String("migrations-dir", "", "Read migrations from this directory instead of the ones built into the binary")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), migrateUsage, os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	// Flags may come before, between or after the command and its arguments
+	params := cli.ParseInterspersed(flags, args)
+	if len(params) == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	command, params := params[0], params[1:]
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	configureLogging(cfg.LogLevel)
+
+	st, err := backend.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+	defer st.Close()
+
+	migrator, err := backend.Migrations(st)
+	if err != nil {
+		log.Fatalf("Cannot migrate DATABASE_URL: %v", err)
+	}
+	database.SetMigrationsDir(*migrationsDir)
+
+	ctx := context.Background()
+	switch command {
+	case "status":
+		if len(params) > 0 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		err = printMigrationStatus(ctx, migrator)
+
+	case "up":
+		if len(params) > 1 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		target := ""
+		if len(params) == 1 {
+			target = params[0]
+		}
+		var applied []string
+		applied, err = migrator.Up(ctx, target, *dryRun)
+		if err == nil || len(applied) > 0 {
+			printMigrated(applied, "apply", "Applied", *dryRun)
+		}
+
+	case "down":
+		steps := 1
+		if len(params) > 1 {
+			flags.Usage()
+			os.Exit(2)
+		}
+		if len(params) == 1 {
+			steps, err = strconv.Atoi(params[0])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps '%s', expected a positive number", params[0])
+			}
+		}
+		var rolledBack []string
+		rolledBack, err = migrator.Down(ctx, steps, *dryRun)
+		if err == nil || len(rolledBack) > 0 {
+			printMigrated(rolledBack, "roll back", "Rolled back", *dryRun)
+		}
+
+	default:
+		fmt.Fprintf(flags.Output(), "Unknown migrate command '%s'\n\n", command)
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+}
+
+// warnPendingMigrations logs the migrations the server starts without when
+// automatic migration is skipped
+func warnPendingMigrations(ctx context.Context, st store.Store) {
+	log.Println("Skipping automatic migrations")
+
+	migrator, err := backend.Migrations(st)
+	if err != nil {
+		return
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to check for pending migrations: %v", err)
+		return
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		log.Printf("Warning: %d migrations are pending, apply them with '%s migrate up'", pending, os.Args[0])
+	}
+}
+
+// printMigrationStatus prints a table of the migrations
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT\tDOWN")
+	for _, status := range statuses {
+		state := "pending"
+		appliedAt := "-"
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.UTC().Format("2006-01-02T15:04:05Z")
+		}
+		if status.Changed {
+			state = "changed"
+		}
+		if status.Missing {
+			state = "missing"
+		}
+		down := "no"
+		if status.Reversible {
+			down = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status.Filename, state, appliedAt, down)
+	}
+	return w.Flush()
+}
+
+// printMigrated prints the migrations that were applied or rolled back
+func printMigrated(filenames []string, verb, done string, dryRun bool) {
+	if len(filenames) == 0 {
+		fmt.Printf("Nothing to %s\n", verb)
+		return
+	}
+	for _, filename := range filenames {
+		if dryRun {
+			fmt.Printf("Would %s %s\n", verb, filename)
+		} else {
+			fmt.Printf("%s %s\n", done, filename)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// APIKey represents a long-lived API key belonging to a user
+type APIKey struct {
+	ID         string       `json:"id"`
+	UserID     string       `json:"user_id"`
+	Name       string       `json:"name"`
+	KeyHash    string       `json:"-"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at,omitempty"`
+	RevokedAt  sql.NullTime `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// Organization represents a tenant; its users, teams and tasks are isolated
+// from other organizations
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
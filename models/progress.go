@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskProgressReport is an entry of the progress history of a task, with the
+// name of the user who reported it
+type TaskProgressReport struct {
+	ID            string    `json:"id"`
+	TaskID        string    `json:"task_id"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedByName string    `json:"created_by_name"`
+	Percent       int       `json:"percent"`
+	Message       string    `json:"message,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,169 @@
+package models
+
+// Response types returned by the MCP tools as structured content. Each tool
+// declares the schema of its response type as its output schema, so field
+// names and JSON tags here are part of the public API.
+
+// TaskWithUsers represents a task with the names of its creator, assignee and team
+type TaskWithUsers struct {
+	ID             string                `json:"id"`
+	Description    string                `json:"description"`
+	Status         string                `json:"status"`
+	CreatedBy      string                `json:"created_by"`
+	CreatedByID    string                `json:"created_by_id"`
+	AssignedTo     string                `json:"assigned_to"`
+	AssignedToID   string                `json:"assigned_to_id"`
+	AssignedTeam   *string               `json:"assigned_team,omitempty"`
+	AssignedTeamID *string               `json:"assigned_team_id,omitempty"`
+	Result         *string               `json:"result,omitempty"`
+	Progress       *TaskProgress         `json:"progress,omitempty"`
+	Comments       []TaskCommentWithUser `json:"comments,omitempty"`
+	IsArchived     bool                  `json:"is_archived"`
+	CreatedAt      string                `json:"created_at"`
+	UpdatedAt      string                `json:"updated_at"`
+	CompletedAt    *string               `json:"completed_at,omitempty"`
+	ArchivedAt     *string               `json:"archived_at,omitempty"`
+}
+
+// TaskWithUserNames is the response of create_task and cancel_task. Those
+// tools used to return the user IDs under created_by and assigned_to and the
+// names under created_by_name and assigned_to_name; created_by and
+// assigned_to now hold the names as in every other task response, and the
+// *_name keys are kept until clients have moved off them.
+type TaskWithUserNames struct {
+	TaskWithUsers
+	// Deprecated: use CreatedBy
+	CreatedByName string `json:"created_by_name"`
+	// Deprecated: use AssignedTo
+	AssignedToName string `json:"assigned_to_name"`
+}
+
+// WithUserNames adds the deprecated *_name keys to a task response
+func (t TaskWithUsers) WithUserNames() TaskWithUserNames {
+	return TaskWithUserNames{TaskWithUsers: t, CreatedByName: t.CreatedBy, AssignedToName: t.AssignedTo}
+}
+
+// TaskProgress is the latest progress reported by the assignee of a task
+type TaskProgress struct {
+	Percent   int    `json:"percent"`
+	Message   string `json:"message,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// TaskWithComment is a task together with the comment that was just added to it
+type TaskWithComment struct {
+	TaskWithUsers
+	CommentAdded TaskCommentWithUser `json:"comment_added"`
+}
+
+// NextTaskResponse is the response of get_next_task; Task is null when no
+// matching task was found
+type NextTaskResponse struct {
+	Task *TaskWithUsers `json:"task"`
+}
+
+// TaskListResponse is the response of list_created_tasks. CreatedBy is set when
+// listing a user's tasks, Team when listing a team's tasks.
+type TaskListResponse struct {
+	Tasks       []TaskWithUsers `json:"tasks"`
+	TotalCount  int             `json:"total_count"`
+	LimitUsed   int             `json:"limit_used"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	CreatedByID string          `json:"created_by_id,omitempty"`
+	Team        string          `json:"team,omitempty"`
+	TeamID      string          `json:"team_id,omitempty"`
+}
+
+// UserWithRoles represents a user with its effective roles
+type UserWithRoles struct {
+	User
+	Roles []string `json:"roles"`
+}
+
+// UserListResponse is the response of list_users
+type UserListResponse struct {
+	Users []UserWithRoles `json:"users"`
+	Count int             `json:"count"`
+	Limit int             `json:"limit"`
+}
+
+// UserTokenResponse is the response of the tools that issue a JWT for a user
+type UserTokenResponse struct {
+	Success bool          `json:"success"`
+	Token   string        `json:"token"`
+	User    UserWithRoles `json:"user"`
+	Message string        `json:"message"`
+}
+
+// DeletedUserResponse is the response of delete_user
+type DeletedUserResponse struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Deleted             bool   `json:"deleted"`
+	OpenTasksReassigned int64  `json:"open_tasks_reassigned"`
+	ReassignedTo        string `json:"reassigned_to,omitempty"`
+}
+
+// TokenInfo describes the credential of the caller
+type TokenInfo struct {
+	UserID        string   `json:"user_id"`
+	UserName      string   `json:"user_name"`
+	OrgID         string   `json:"org_id"`
+	IsAdmin       bool     `json:"is_admin"`
+	Roles         []string `json:"roles"`
+	Permissions   []string `json:"permissions"`
+	APIKeyID      string   `json:"api_key_id,omitempty"`
+	IssuedAt      string   `json:"issued_at"`
+	ExpiresAt     string   `json:"expires_at"`
+	RemainingTime string   `json:"remaining_time"`
+}
+
+// TokenInfoResponse is the response of get_token_info
+type TokenInfoResponse struct {
+	Success   bool      `json:"success"`
+	TokenInfo TokenInfo `json:"token_info"`
+	Message   string    `json:"message"`
+}
+
+// CreatedAPIKeyResponse is the response of create_api_key; APIKey is only
+// ever returned here
+type CreatedAPIKeyResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	APIKey    string `json:"api_key"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"user_name"`
+	CreatedAt string `json:"created_at"`
+	Message   string `json:"message"`
+}
+
+// RevokedAPIKeyResponse is the response of revoke_api_key
+type RevokedAPIKeyResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	UserID    string `json:"user_id"`
+	RevokedAt string `json:"revoked_at"`
+}
+
+// TeamWithMembers represents a team with its members
+type TeamWithMembers struct {
+	Team
+	Members []TeamMember `json:"members"`
+}
+
+// TeamListResponse is the response of list_teams
+type TeamListResponse struct {
+	Teams []TeamWithMembers `json:"teams"`
+	Count int               `json:"count"`
+}
+
+// TeamMembershipResponse is the response of add_team_member and
+// remove_team_member; Removed is set by remove_team_member
+type TeamMembershipResponse struct {
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+	IsLead   bool   `json:"is_lead"`
+	Removed  bool   `json:"removed,omitempty"`
+}
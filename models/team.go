@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// Team represents a group of users supervised by team leads
+type Team struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TeamMember represents a user's membership in a team
+type TeamMember struct {
+	TeamID    string    `json:"team_id"`
+	UserID    string    `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	IsLead    bool      `json:"is_lead"`
+	CreatedAt time.Time `json:"created_at"`
+}
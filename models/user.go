@@ -7,9 +7,18 @@ import (
 // User represents a user in the system
 type User struct {
 	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
 	IsAdmin     bool      `json:"is_admin"`
+	IsDisabled  bool      `json:"is_disabled"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// TokenGeneration is incremented when the user's tokens are revoked;
+	// tokens issued for an older generation are rejected
+	TokenGeneration int `json:"-"`
 }
+
+// DeletedUserName is shown as the author of comments and progress reports
+// whose user was deleted; their created_by is empty
+const DeletedUserName = "deleted user"
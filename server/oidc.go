@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"golang.org/x/oauth2"
+)
+
+// Cookies holding the login state between /auth/login and /auth/callback
+const (
+	oidcStateCookie    = "oidc_state"
+	oidcNonceCookie    = "oidc_nonce"
+	oidcVerifierCookie = "oidc_verifier"
+	oidcCookieMaxAge   = 10 * time.Minute
+)
+
+// errIdentityNotLinked is returned when an IdP subject has no matching user
+var errIdentityNotLinked = errors.New("no user is linked to this identity")
+
+// OIDCConfig holds the settings for the OIDC authorization-code flow
+type OIDCConfig struct {
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	UsernameClaim string
+	// AutoProvision creates a user on first login when the subject is not linked
+	AutoProvision bool
+	// LinkByName links an unlinked subject to an existing user with the same
+	// name, if the identity provider vouches for the name (see
+	// verifiedUsername); admin accounts are never linked
+	LinkByName bool
+	// Organization is the name of the organization new identities are linked
+	// to or provisioned in
+	Organization string
+}
+
+// OIDCHandler serves the login and callback endpoints that exchange an
+// identity provider login for one of our JWTs
+type OIDCHandler struct {
+	config       OIDCConfig
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config oauth2.Config
+	jwtManager   *auth.JWTManager
+	store        store.Store
+}
+
+// oidcUser is the user row an identity was mapped to
+type oidcUser struct {
+	ID         string
+	OrgID      string
+	Name       string
+	IsAdmin    bool
+	IsDisabled bool
+	// TokenGeneration is the generation of the tokens issued to the user
+	TokenGeneration int
+}
+
+// NewOIDCHandler discovers the identity provider and creates the handler
+func NewOIDCHandler(ctx context.Context, config OIDCConfig, jwtManager *auth.JWTManager, st store.Store) (*OIDCHandler, error) {
+	provider, err := oidc.NewProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+
+	if config.LinkByName && config.UsernameClaim != "email" && config.UsernameClaim != "sub" {
+		log.Printf("Warning: OIDC_LINK_BY_NAME has no effect with OIDC_USERNAME_CLAIM=%s; users can only be linked by their verified email or subject", config.UsernameClaim)
+	}
+
+	return &OIDCHandler{
+		config:   config,
+		verifier: provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		oauth2Config: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		jwtManager: jwtManager,
+		store:      st,
+	}, nil
+}
+
+// Login redirects the browser to the identity provider
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	state, err := randomString()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	nonce, err := randomString()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	setLoginCookie(w, r, oidcStateCookie, state)
+	setLoginCookie(w, r, oidcNonceCookie, nonce)
+	setLoginCookie(w, r, oidcVerifierCookie, verifier)
+
+	authURL := h.oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes the login, maps the IdP subject to a user and returns a JWT
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	if errParam := r.URL.Query().Get("error"); errParam != "" {
+		writeJSONError(w, http.StatusUnauthorized, fmt.Sprintf("login failed: %s %s", errParam, r.URL.Query().Get("error_description")))
+		return
+	}
+
+	state, err := r.Cookie(oidcStateCookie)
+	if err != nil || state.Value == "" || state.Value != r.URL.Query().Get("state") {
+		writeJSONError(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+	nonce, err := r.Cookie(oidcNonceCookie)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+	verifier, err := r.Cookie(oidcVerifierCookie)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid login state")
+		return
+	}
+
+	// The login state is single use
+	clearLoginCookie(w, oidcStateCookie)
+	clearLoginCookie(w, oidcNonceCookie)
+	clearLoginCookie(w, oidcVerifierCookie)
+
+	oauth2Token, err := h.oauth2Config.Exchange(r.Context(), r.URL.Query().Get("code"), oauth2.VerifierOption(verifier.Value))
+	if err != nil {
+		log.Printf("Error exchanging OIDC code: %v", err)
+		writeJSONError(w, http.StatusUnauthorized, "failed to exchange authorization code")
+		return
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, "identity provider did not return an ID token")
+		return
+	}
+
+	idToken, err := h.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		log.Printf("Error verifying OIDC ID token: %v", err)
+		writeJSONError(w, http.StatusUnauthorized, "invalid ID token")
+		return
+	}
+	if idToken.Nonce != nonce.Value {
+		writeJSONError(w, http.StatusUnauthorized, "invalid ID token nonce")
+		return
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "invalid ID token claims")
+		return
+	}
+
+	user, err := h.resolveUser(r.Context(), idToken.Issuer, idToken.Subject, claims)
+	if err != nil {
+		if errors.Is(err, errIdentityNotLinked) {
+			writeJSONError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		log.Printf("Error resolving OIDC user: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if user.IsDisabled {
+		writeJSONError(w, http.StatusForbidden, "user is disabled")
+		return
+	}
+
+	token, err := h.jwtManager.GenerateToken(user.ID, user.OrgID, user.IsAdmin, user.TokenGeneration)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+
+	log.Printf("User %s logged in via OIDC", user.Name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":     token,
+		"user_id":   user.ID,
+		"user_name": user.Name,
+		"org_id":    user.OrgID,
+		"is_admin":  user.IsAdmin,
+	})
+}
+
+// resolveUser maps an IdP subject to a user, linking or provisioning one
+// according to the configuration
+func (h *OIDCHandler) resolveUser(ctx context.Context, issuer, subject string, claims map[string]interface{}) (*oidcUser, error) {
+	linked, err := h.store.GetUserByIdentity(ctx, issuer, subject)
+	if err == nil {
+		return toOIDCUser(&linked.User), nil
+	}
+	if err != store.ErrNotFound {
+		log.Printf("Error looking up OIDC identity: %v", err)
+		return nil, fmt.Errorf("database error")
+	}
+
+	username, _ := claims[h.config.UsernameClaim].(string)
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, fmt.Errorf("%w: ID token has no '%s' claim", errIdentityNotLinked, h.config.UsernameClaim)
+	}
+
+	org, err := h.store.GetOrganizationByName(ctx, h.config.Organization)
+	if err == store.ErrNotFound {
+		return nil, fmt.Errorf("organization '%s' does not exist", h.config.Organization)
+	}
+	if err != nil {
+		log.Printf("Error finding organization: %v", err)
+		return nil, fmt.Errorf("database error")
+	}
+
+	existing, err := h.store.GetUserByName(ctx, org.ID, username)
+	switch {
+	case err == nil && h.config.LinkByName:
+		if err := h.checkLinkByName(existing, claims); err != nil {
+			return nil, err
+		}
+		log.Printf("Linking OIDC subject %s to existing user %s", subject, username)
+		if err := h.store.LinkIdentity(ctx, existing.ID, issuer, subject); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %v", err)
+		}
+		return toOIDCUser(&existing.User), nil
+	case err == nil:
+		return nil, fmt.Errorf("%w: user '%s' already exists, ask an admin to enable linking", errIdentityNotLinked, username)
+	case err == store.ErrNotFound && h.config.AutoProvision:
+		description, _ := claims["email"].(string)
+		user := models.User{OrgID: org.ID, Name: username, Description: description}
+		if err := h.store.CreateUserWithIdentity(ctx, &user, issuer, subject); err != nil {
+			return nil, fmt.Errorf("failed to create user: %v", err)
+		}
+		log.Printf("Provisioned user %s for OIDC subject %s", username, subject)
+		return toOIDCUser(&user), nil
+	case err == store.ErrNotFound:
+		return nil, fmt.Errorf("%w: ask an admin to create user '%s'", errIdentityNotLinked, username)
+	default:
+		log.Printf("Error finding user by name: %v", err)
+		return nil, fmt.Errorf("database error")
+	}
+}
+
+// checkLinkByName refuses to link a subject to an existing user by name unless
+// the identity provider verified the name, since anyone able to choose the
+// claim at the provider would otherwise take over the user. Admin accounts
+// are never linked this way.
+func (h *OIDCHandler) checkLinkByName(user *models.UserWithRoles, claims map[string]interface{}) error {
+	if user.IsAdmin || slices.Contains(user.Roles, string(auth.RoleAdmin)) {
+		return fmt.Errorf("%w: user '%s' is an admin and cannot be linked by name", errIdentityNotLinked, user.Name)
+	}
+	if !verifiedUsername(h.config.UsernameClaim, claims) {
+		return fmt.Errorf("%w: the '%s' claim is not verified by the identity provider, so user '%s' cannot be linked by name", errIdentityNotLinked, h.config.UsernameClaim, user.Name)
+	}
+	return nil
+}
+
+// verifiedUsername reports whether the identity provider vouches for the
+// username claim: the subject is assigned by the provider, and an email only
+// counts when the provider marked it as verified
+func verifiedUsername(usernameClaim string, claims map[string]interface{}) bool {
+	switch usernameClaim {
+	case "sub":
+		return true
+	case "email":
+		verified, _ := claims["email_verified"].(bool)
+		return verified
+	default:
+		return false
+	}
+}
+
+// toOIDCUser keeps the fields of a user needed to issue a token
+func toOIDCUser(user *models.User) *oidcUser {
+	return &oidcUser{
+		ID:              user.ID,
+		OrgID:           user.OrgID,
+		Name:            user.Name,
+		IsAdmin:         user.IsAdmin,
+		IsDisabled:      user.IsDisabled,
+		TokenGeneration: user.TokenGeneration,
+	}
+}
+
+// randomString returns a URL-safe random string for state and nonce values
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// setLoginCookie stores a short-lived value for the callback request
+func setLoginCookie(w http.ResponseWriter, r *http.Request, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   int(oidcCookieMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearLoginCookie removes a login cookie
+func clearLoginCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
+
+// writeJSONError writes a JSON error response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,344 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/memory"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "simple-task-mcp"
+
+// stubIdP is an OpenID provider that issues codes for the claims a test
+// chooses, checking the PKCE verifier when they are exchanged
+type stubIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]stubLogin
+}
+
+// stubLogin is a login waiting for its code to be exchanged
+type stubLogin struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	idp := &stubIdP{key: key, codes: make(map[string]stubLogin)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", idp.exchange)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// exchange serves the token endpoint
+func (idp *stubIdP) exchange(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	idp.mu.Lock()
+	login, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != login.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, login.claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize plays the login at the provider: it issues a code for the
+// authorization URL our login endpoint redirected to, with the claims of the
+// user who logged in
+func (idp *stubIdP) authorize(t *testing.T, authURL *url.URL, claims jwt.MapClaims) string {
+	t.Helper()
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("login does not use PKCE: %s", authURL)
+	}
+
+	idTokenClaims := jwt.MapClaims{
+		"iss":   idp.server.URL,
+		"aud":   testClientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		idTokenClaims[name] = value
+	}
+
+	code := rand.Text()
+	idp.mu.Lock()
+	idp.codes[code] = stubLogin{challenge: query.Get("code_challenge"), claims: idTokenClaims}
+	idp.mu.Unlock()
+	return code
+}
+
+// oidcTest is an OIDC handler on an in-memory store, logging in at a stub IdP
+type oidcTest struct {
+	idp     *stubIdP
+	handler *OIDCHandler
+	st      store.Store
+	orgID   string
+}
+
+func newOIDCTest(t *testing.T, config OIDCConfig) *oidcTest {
+	t.Helper()
+	idp := newStubIdP(t)
+	st := memory.New()
+	org, err := st.EnsureOrganization(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("EnsureOrganization: %v", err)
+	}
+
+	config.IssuerURL = idp.server.URL
+	config.ClientID = testClientID
+	config.ClientSecret = "secret"
+	config.RedirectURL = "http://localhost/auth/callback"
+	config.Organization = "default"
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "preferred_username"
+	}
+	handler, err := NewOIDCHandler(context.Background(), config, auth.NewJWTManager("test-secret"), st)
+	if err != nil {
+		t.Fatalf("NewOIDCHandler: %v", err)
+	}
+	return &oidcTest{idp: idp, handler: handler, st: st, orgID: org.ID}
+}
+
+// addUser creates a user of the organization
+func (o *oidcTest) addUser(t *testing.T, name string, isAdmin bool) models.User {
+	t.Helper()
+	user := models.User{OrgID: o.orgID, Name: name, IsAdmin: isAdmin}
+	if err := o.st.CreateUser(context.Background(), &user, nil); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+// login starts a login and returns the authorization URL and the login cookies
+func (o *oidcTest) login(t *testing.T) (*url.URL, []*http.Cookie) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	o.handler.Login(recorder, httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want %d", recorder.Code, http.StatusFound)
+	}
+	authURL, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse authorization URL: %v", err)
+	}
+	return authURL, recorder.Result().Cookies()
+}
+
+// callback completes a login and returns the status and the decoded body
+func (o *oidcTest) callback(t *testing.T, query url.Values, cookies []*http.Cookie) (int, map[string]any) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, "/auth/callback?"+query.Encode(), nil)
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+	recorder := httptest.NewRecorder()
+	o.handler.Callback(recorder, request)
+
+	var body map[string]any
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode callback response %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, body
+}
+
+// loginAs runs the whole flow for an IdP user with the claims
+func (o *oidcTest) loginAs(t *testing.T, claims jwt.MapClaims) (int, map[string]any) {
+	t.Helper()
+	authURL, cookies := o.login(t)
+	code := o.idp.authorize(t, authURL, claims)
+	return o.callback(t, url.Values{"code": {code}, "state": {authURL.Query().Get("state")}}, cookies)
+}
+
+func TestOIDCCallback(t *testing.T) {
+	o := newOIDCTest(t, OIDCConfig{AutoProvision: true})
+
+	status, body := o.loginAs(t, jwt.MapClaims{"sub": "subject-1", "preferred_username": "alice"})
+	if status != http.StatusOK {
+		t.Fatalf("first login: status = %d, body = %v", status, body)
+	}
+	if body["user_name"] != "alice" || body["is_admin"] != false {
+		t.Errorf("first login: body = %v, want non-admin alice", body)
+	}
+	token, _ := body["token"].(string)
+	claims, err := auth.NewJWTManager("test-secret").ValidateToken(token)
+	if err != nil {
+		t.Fatalf("issued token: %v", err)
+	}
+	if claims.UserID != body["user_id"] {
+		t.Errorf("token of user %s, want %v", claims.UserID, body["user_id"])
+	}
+
+	// The subject is linked now, so the username claim no longer matters
+	status, body = o.loginAs(t, jwt.MapClaims{"sub": "subject-1", "preferred_username": "renamed"})
+	if status != http.StatusOK || body["user_name"] != "alice" {
+		t.Errorf("second login: status = %d, body = %v, want alice", status, body)
+	}
+}
+
+func TestOIDCCallbackLoginState(t *testing.T) {
+	o := newOIDCTest(t, OIDCConfig{AutoProvision: true})
+	claims := jwt.MapClaims{"sub": "subject-1", "preferred_username": "alice"}
+
+	tests := []struct {
+		name   string
+		modify func(query url.Values, cookies []*http.Cookie) []*http.Cookie
+		status int
+	}{
+		{"wrong state", func(query url.Values, cookies []*http.Cookie) []*http.Cookie {
+			query.Set("state", "forged")
+			return cookies
+		}, http.StatusBadRequest},
+		{"no cookies", func(query url.Values, cookies []*http.Cookie) []*http.Cookie {
+			return nil
+		}, http.StatusBadRequest},
+		{"wrong PKCE verifier", func(query url.Values, cookies []*http.Cookie) []*http.Cookie {
+			return replaceCookie(cookies, oidcVerifierCookie, "forged-verifier-forged-verifier-forged-verifier")
+		}, http.StatusUnauthorized},
+		{"wrong nonce", func(query url.Values, cookies []*http.Cookie) []*http.Cookie {
+			return replaceCookie(cookies, oidcNonceCookie, "forged")
+		}, http.StatusUnauthorized},
+		{"provider error", func(query url.Values, cookies []*http.Cookie) []*http.Cookie {
+			query.Set("error", "access_denied")
+			return cookies
+		}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, cookies := o.login(t)
+			query := url.Values{"code": {o.idp.authorize(t, authURL, claims)}, "state": {authURL.Query().Get("state")}}
+			cookies = tt.modify(query, cookies)
+
+			status, body := o.callback(t, query, cookies)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d (body %v)", status, tt.status, body)
+			}
+			if _, ok := body["token"]; ok {
+				t.Errorf("token issued despite %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestOIDCLinkByName(t *testing.T) {
+	tests := []struct {
+		name          string
+		usernameClaim string
+		user          string
+		isAdmin       bool
+		claims        jwt.MapClaims
+		linked        bool
+	}{
+		{"unverified claim", "preferred_username", "bob", false, jwt.MapClaims{"preferred_username": "bob"}, false},
+		{"unverified email", "email", "bob@example.com", false, jwt.MapClaims{"email": "bob@example.com", "email_verified": false}, false},
+		{"verified email", "email", "bob@example.com", false, jwt.MapClaims{"email": "bob@example.com", "email_verified": true}, true},
+		{"admin", "email", "root@example.com", true, jwt.MapClaims{"email": "root@example.com", "email_verified": true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOIDCTest(t, OIDCConfig{LinkByName: true, UsernameClaim: tt.usernameClaim})
+			user := o.addUser(t, tt.user, tt.isAdmin)
+
+			claims := jwt.MapClaims{"sub": "subject-1"}
+			for name, value := range tt.claims {
+				claims[name] = value
+			}
+			status, body := o.loginAs(t, claims)
+
+			if tt.linked {
+				if status != http.StatusOK || body["user_id"] != user.ID {
+					t.Errorf("status = %d, body = %v, want a token for %s", status, body, user.ID)
+				}
+				return
+			}
+			if status != http.StatusForbidden {
+				t.Errorf("status = %d, body = %v, want %d", status, body, http.StatusForbidden)
+			}
+			if _, err := o.st.GetUserByIdentity(context.Background(), o.idp.server.URL, "subject-1"); err != store.ErrNotFound {
+				t.Errorf("identity linked: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+// replaceCookie returns the cookies with the value of one replaced
+func replaceCookie(cookies []*http.Cookie, name, value string) []*http.Cookie {
+	replaced := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			cookie = &http.Cookie{Name: name, Value: value}
+		}
+		replaced = append(replaced, cookie)
+	}
+	return replaced
+}
@@ -0,0 +1,55 @@
+// Package backend opens the store implementation selected by the scheme of
+// the database URL
+package backend
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/memory"
+	"github.com/dushes/simple-task-mcp/store/postgres"
+	"github.com/dushes/simple-task-mcp/store/sqlite"
+)
+
+// IsMemory reports whether the database URL selects the in-memory store,
+// which starts empty on every run
+func IsMemory(databaseURL string) bool {
+	return scheme(databaseURL) == "memory"
+}
+
+// Open opens the store for the database URL: postgres:// or postgresql://
+// for PostgreSQL, sqlite:// for a SQLite database file, memory:// for the
+// in-memory store. Connection strings without a scheme are passed to
+// PostgreSQL as before.
+func Open(databaseURL string) (store.Store, error) {
+	switch scheme(databaseURL) {
+	case "", "postgres", "postgresql":
+		return postgres.Open(databaseURL)
+	case "sqlite":
+		return sqlite.Open(databaseURL)
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported database URL scheme '%s', expected postgres://, sqlite:// or memory://", scheme(databaseURL))
+	}
+}
+
+// Migrations returns the migrator of a store with a SQL schema
+func Migrations(st store.Store) (*database.Migrator, error) {
+	migrating, ok := st.(interface{ Migrations() *database.Migrator })
+	if !ok {
+		return nil, fmt.Errorf("the store has no SQL migrations")
+	}
+	return migrating.Migrations(), nil
+}
+
+// scheme returns the scheme of the database URL
+func scheme(databaseURL string) string {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme
+}
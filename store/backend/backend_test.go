@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/database"
+)
+
+// schema returns the columns of every table as "table.column"
+func schema(t *testing.T, db *sql.DB, query string) []string {
+	t.Helper()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			t.Fatalf("scan schema: %v", err)
+		}
+		columns = append(columns, table+"."+column)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// TestSchemaParity checks that the SQLite schema has the tables and columns
+// the PostgreSQL migrations create, since both back the same queries
+func TestSchemaParity(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	for _, url := range []string{databaseURL, "sqlite://" + path} {
+		st, err := Open(url)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		err = st.Migrate(ctx)
+		st.Close()
+		if err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+	}
+
+	pg, err := database.Connect(databaseURL)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer pg.Close()
+	lite, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open SQLite: %v", err)
+	}
+	defer lite.Close()
+
+	want := schema(t, pg, `
+		SELECT table_name, column_name FROM information_schema.columns
+		WHERE table_schema = current_schema()`)
+	got := schema(t, lite, `
+		SELECT m.name, p.name FROM sqlite_master m, pragma_table_info(m.name) p
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'`)
+
+	missing, extra := diff(want, got), diff(got, want)
+	for _, column := range missing {
+		t.Errorf("SQLite schema lacks %s", column)
+	}
+	for _, column := range extra {
+		t.Errorf("SQLite schema has %s, which PostgreSQL lacks", column)
+	}
+}
+
+// diff returns the sorted items of a that are not in b
+func diff(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, item := range b {
+		in[item] = true
+	}
+	var out []string
+	for _, item := range a {
+		if !in[item] {
+			out = append(out, item)
+		}
+	}
+	return out
+}
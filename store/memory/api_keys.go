@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// CreateAPIKey inserts an API key
+func (s *Store) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.apiKeys[key.ID]; ok {
+		return store.ErrDuplicate
+	}
+	if _, ok := s.users[key.UserID]; !ok {
+		return store.ErrNotFound
+	}
+
+	key.CreatedAt = now()
+	stored := *key
+	s.apiKeys[key.ID] = &stored
+	return nil
+}
+
+// GetAPIKey looks up an API key by ID
+func (s *Store) GetAPIKey(ctx context.Context, keyID string) (*models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	found := *key
+	return &found, nil
+}
+
+// TouchAPIKey updates the last used time of an API key
+func (s *Store) TouchAPIKey(ctx context.Context, keyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.apiKeys[keyID]; ok {
+		key.LastUsedAt = sql.NullTime{Time: now(), Valid: true}
+	}
+	return nil
+}
+
+// RevokeAPIKey revokes an API key
+func (s *Store) RevokeAPIKey(ctx context.Context, keyID string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[keyID]
+	if !ok {
+		return time.Time{}, store.ErrNotFound
+	}
+	key.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+	return key.RevokedAt.Time, nil
+}
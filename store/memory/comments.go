@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// ListComments returns the comments of a task in chronological order
+func (s *Store) ListComments(ctx context.Context, taskID string) ([]models.TaskCommentWithUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	comments := []models.TaskCommentWithUser{}
+	for _, comment := range s.comments[taskID] {
+		comments = append(comments, *s.commentWithUser(comment))
+	}
+	return comments, nil
+}
+
+// AddComment adds a comment to a task
+func (s *Store) AddComment(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addComment(taskID, userID, comment)
+}
+
+// addComment inserts a comment and returns it with the commenter's name
+func (s *Store) addComment(taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	if _, ok := s.taskIndex[taskID]; !ok {
+		return nil, store.ErrNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return nil, store.ErrNotFound
+	}
+
+	added := &models.TaskComment{
+		ID:        newID(),
+		TaskID:    taskID,
+		CreatedBy: userID,
+		Comment:   comment,
+		CreatedAt: now(),
+	}
+	s.comments[taskID] = append(s.comments[taskID], added)
+
+	return s.commentWithUser(added), nil
+}
+
+// authorName returns the name of a comment or progress report author, or the
+// deleted user placeholder once the author is gone
+func (s *Store) authorName(userID string) string {
+	if user, ok := s.users[userID]; ok {
+		return user.Name
+	}
+	return models.DeletedUserName
+}
+
+// commentWithUser returns a copy of the comment with the commenter's name
+func (s *Store) commentWithUser(comment *models.TaskComment) *models.TaskCommentWithUser {
+	return &models.TaskCommentWithUser{
+		ID:            comment.ID,
+		TaskID:        comment.TaskID,
+		CreatedBy:     comment.CreatedBy,
+		CreatedByName: s.authorName(comment.CreatedBy),
+		Comment:       comment.Comment,
+		CreatedAt:     comment.CreatedAt,
+	}
+}
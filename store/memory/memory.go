@@ -0,0 +1,98 @@
+// Package memory implements the store in process memory. Data is lost when
+// the server stops; it is meant for tests and local demos.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/google/uuid"
+)
+
+// Store is the in-memory implementation of store.Store
+type Store struct {
+	mu sync.Mutex
+
+	orgs       map[string]*models.Organization
+	users      map[string]*models.User
+	roles      map[string][]auth.Role
+	identities map[identity]string
+	teams      map[string]*models.Team
+	members    map[string]map[string]*models.TeamMember
+	// tasks are kept in creation order
+	tasks     []*task
+	taskIndex map[string]*task
+	comments  map[string][]*models.TaskComment
+	reports   []*progressReport
+	apiKeys   map[string]*models.APIKey
+
+	watchers store.TaskWatchers
+}
+
+var _ store.Store = (*Store)(nil)
+
+// identity is an identity provider subject
+type identity struct {
+	issuer  string
+	subject string
+}
+
+// task is a task with its latest progress report
+type task struct {
+	models.Task
+	progress *models.TaskProgress
+}
+
+// progressReport is an entry of the progress history of a task
+type progressReport struct {
+	id        string
+	taskID    string
+	createdBy string
+	percent   int
+	message   string
+	createdAt time.Time
+}
+
+// New creates an empty store
+func New() *Store {
+	return &Store{
+		orgs:       make(map[string]*models.Organization),
+		users:      make(map[string]*models.User),
+		roles:      make(map[string][]auth.Role),
+		identities: make(map[identity]string),
+		teams:      make(map[string]*models.Team),
+		members:    make(map[string]map[string]*models.TeamMember),
+		taskIndex:  make(map[string]*task),
+		comments:   make(map[string][]*models.TaskComment),
+		apiKeys:    make(map[string]*models.APIKey),
+	}
+}
+
+// Migrate does nothing, there is no schema
+func (s *Store) Migrate(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing, there are no connections
+func (s *Store) Close() error {
+	return nil
+}
+
+// WatchTasks subscribes to the changes of the tasks assigned to the user
+func (s *Store) WatchTasks(userID string) (<-chan struct{}, func()) {
+	return s.watchers.Watch(userID)
+}
+
+// newID generates an ID like the database defaults do
+func newID() string {
+	return uuid.New().String()
+}
+
+// now returns the current time as the database would store it
+func now() time.Time {
+	return time.Now().UTC()
+}
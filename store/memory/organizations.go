@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// GetOrganizationByName looks up an organization by its name
+func (s *Store) GetOrganizationByName(ctx context.Context, name string) (*models.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := s.orgByName(name)
+	if org == nil {
+		return nil, store.ErrNotFound
+	}
+	found := *org
+	return &found, nil
+}
+
+// EnsureOrganization finds or creates an organization
+func (s *Store) EnsureOrganization(ctx context.Context, name string) (*models.Organization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := s.orgByName(name)
+	if org == nil {
+		created := now()
+		org = &models.Organization{ID: newID(), Name: name, CreatedAt: created, UpdatedAt: created}
+		s.orgs[org.ID] = org
+	}
+	found := *org
+	return &found, nil
+}
+
+// orgByName returns the organization with the name, or nil
+func (s *Store) orgByName(name string) *models.Organization {
+	for _, org := range s.orgs {
+		if org.Name == name {
+			return org
+		}
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// isOpen reports whether a task with the status still needs work
+func isOpen(status models.TaskStatus) bool {
+	return status != models.StatusCompleted && status != models.StatusCancelled
+}
+
+// withUsers returns a copy of the task with the names of its creator,
+// assignee and team
+func (s *Store) withUsers(t *task) *models.TaskWithUsers {
+	result := models.TaskWithUsers{
+		ID:           t.ID,
+		Description:  t.Description,
+		Status:       string(t.Status),
+		CreatedBy:    s.users[t.CreatedBy].Name,
+		CreatedByID:  t.CreatedBy,
+		AssignedTo:   s.users[t.AssignedTo].Name,
+		AssignedToID: t.AssignedTo,
+		IsArchived:   t.IsArchived,
+		CreatedAt:    t.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:    t.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+
+	if t.Result.Valid {
+		taskResult := t.Result.String
+		result.Result = &taskResult
+	}
+
+	if t.AssignedTeamID.Valid {
+		if team, ok := s.teams[t.AssignedTeamID.String]; ok {
+			result.AssignedTeamID = &team.ID
+			result.AssignedTeam = &team.Name
+		}
+	}
+
+	if t.CompletedAt.Valid {
+		completedAt := t.CompletedAt.Time.Format("2006-01-02T15:04:05Z")
+		result.CompletedAt = &completedAt
+	}
+
+	if t.ArchivedAt.Valid {
+		archivedAt := t.ArchivedAt.Time.Format("2006-01-02T15:04:05Z")
+		result.ArchivedAt = &archivedAt
+	}
+
+	if t.progress != nil {
+		progress := *t.progress
+		result.Progress = &progress
+	}
+
+	return &result
+}
+
+// matches reports whether the task is selected by the filter
+func (s *Store) matches(t *task, filter store.TaskFilter) bool {
+	// Tasks never leak across organizations
+	if t.OrgID != filter.OrgID {
+		return false
+	}
+	if filter.CreatedBy != "" && t.CreatedBy != filter.CreatedBy {
+		return false
+	}
+	if filter.AssignedTo != "" && t.AssignedTo != filter.AssignedTo {
+		return false
+	}
+	if filter.TeamID != "" {
+		members := s.members[filter.TeamID]
+		_, creatorIsMember := members[t.CreatedBy]
+		_, assigneeIsMember := members[t.AssignedTo]
+		assignedToTeam := t.AssignedTeamID.Valid && t.AssignedTeamID.String == filter.TeamID
+		if !creatorIsMember && !assigneeIsMember && !assignedToTeam {
+			return false
+		}
+	}
+	if len(filter.Statuses) > 0 {
+		found := false
+		for _, status := range filter.Statuses {
+			if t.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.ExcludeArchived && t.IsArchived {
+		return false
+	}
+	return true
+}
+
+// CreateTask inserts a task
+func (s *Store) CreateTask(ctx context.Context, newTask *models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.taskIndex[newTask.ID]; ok {
+		return store.ErrDuplicate
+	}
+	if _, ok := s.users[newTask.CreatedBy]; !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[newTask.AssignedTo]; !ok {
+		return store.ErrNotFound
+	}
+
+	created := now()
+	newTask.CreatedAt = created
+	newTask.UpdatedAt = created
+
+	t := &task{Task: *newTask}
+	s.tasks = append(s.tasks, t)
+	s.taskIndex[t.ID] = t
+
+	s.watchers.Notify(t.AssignedTo)
+	return nil
+}
+
+// GetTask loads a task of the organization
+func (s *Store) GetTask(ctx context.Context, orgID, taskID string) (*models.TaskWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok || t.OrgID != orgID {
+		return nil, store.ErrNotFound
+	}
+	return s.withUsers(t), nil
+}
+
+// ListTasks returns the tasks matching the filter
+func (s *Store) ListTasks(ctx context.Context, filter store.TaskFilter) ([]models.TaskWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := []models.TaskWithUsers{}
+	for i := range s.tasks {
+		t := s.tasks[i]
+		if filter.NewestFirst {
+			t = s.tasks[len(s.tasks)-1-i]
+		}
+		if !s.matches(t, filter) {
+			continue
+		}
+		tasks = append(tasks, *s.withUsers(t))
+		if filter.Limit > 0 && len(tasks) == filter.Limit {
+			break
+		}
+	}
+
+	return tasks, nil
+}
+
+// CountTasks counts the tasks matching the filter
+func (s *Store) CountTasks(ctx context.Context, filter store.TaskFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, t := range s.tasks {
+		if s.matches(t, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CompleteTask marks a task as completed
+func (s *Store) CompleteTask(ctx context.Context, taskID, result string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	completed := now()
+	t.Status = models.StatusCompleted
+	t.Result = sql.NullString{String: result, Valid: true}
+	t.CompletedAt = sql.NullTime{Time: completed, Valid: true}
+	t.UpdatedAt = completed
+
+	s.watchers.Notify(t.AssignedTo)
+	return nil
+}
+
+// CancelTask marks a task as cancelled, unless it was finished in the meantime
+func (s *Store) CancelTask(ctx context.Context, taskID, result string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if !isOpen(t.Status) {
+		return store.ErrTaskClosed
+	}
+
+	t.Status = models.StatusCancelled
+	t.Result = sql.NullString{String: result, Valid: true}
+	t.UpdatedAt = now()
+
+	s.watchers.Notify(t.AssignedTo)
+	return nil
+}
+
+// ReassignTask assigns an open task to another user
+func (s *Store) ReassignTask(ctx context.Context, taskID, assigneeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok || !isOpen(t.Status) {
+		return store.ErrTaskClosed
+	}
+	if _, ok := s.users[assigneeID]; !ok {
+		return store.ErrNotFound
+	}
+
+	t.AssignedTo = assigneeID
+	t.UpdatedAt = now()
+
+	s.watchers.Notify(assigneeID)
+	return nil
+}
+
+// WaitForUser updates the task status and adds the comment in one step
+func (s *Store) WaitForUser(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	added, err := s.addComment(taskID, userID, comment)
+	if err != nil {
+		return nil, err
+	}
+	t.Status = models.StatusWaitingForUser
+	t.UpdatedAt = now()
+
+	s.watchers.Notify(t.AssignedTo)
+	return added, nil
+}
+
+// RespondToTask updates the task status and adds the comment in one step
+func (s *Store) RespondToTask(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	added, err := s.addComment(taskID, userID, comment)
+	if err != nil {
+		return nil, err
+	}
+	t.Status = models.StatusPending
+	t.UpdatedAt = now()
+
+	s.watchers.Notify(t.AssignedTo)
+	return added, nil
+}
+
+// ReportProgress stores the report on the task and in its history
+func (s *Store) ReportProgress(ctx context.Context, taskID, userID string, percent int, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.taskIndex[taskID]
+	if !ok || !isOpen(t.Status) {
+		return store.ErrTaskClosed
+	}
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	// Reporting progress means work has started, but a task waiting for user
+	// keeps waiting for the answer to its question
+	reported := now()
+	if t.Status != models.StatusWaitingForUser {
+		t.Status = models.StatusInProgress
+	}
+	t.progress = &models.TaskProgress{
+		Percent:   percent,
+		Message:   message,
+		UpdatedAt: reported.Format("2006-01-02T15:04:05Z"),
+	}
+	t.UpdatedAt = reported
+
+	s.reports = append(s.reports, &progressReport{
+		id:        newID(),
+		taskID:    taskID,
+		createdBy: userID,
+		percent:   percent,
+		message:   message,
+		createdAt: reported,
+	})
+
+	s.watchers.Notify(t.AssignedTo)
+	return nil
+}
+
+// ListProgress returns the progress history of a task in chronological order
+func (s *Store) ListProgress(ctx context.Context, taskID string) ([]models.TaskProgressReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports := []models.TaskProgressReport{}
+	for _, report := range s.reports {
+		if report.taskID != taskID {
+			continue
+		}
+		reports = append(reports, models.TaskProgressReport{
+			ID:            report.id,
+			TaskID:        report.taskID,
+			CreatedBy:     report.createdBy,
+			CreatedByName: s.authorName(report.createdBy),
+			Percent:       report.percent,
+			Message:       report.message,
+			CreatedAt:     report.createdAt,
+		})
+	}
+	return reports, nil
+}
@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// CreateTeam inserts a team
+func (s *Store) CreateTeam(ctx context.Context, team *models.Team) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.teamByName(team.OrgID, team.Name) != nil {
+		return store.ErrDuplicate
+	}
+
+	created := now()
+	team.ID = newID()
+	team.CreatedAt = created
+	team.UpdatedAt = created
+
+	stored := *team
+	s.teams[team.ID] = &stored
+	s.members[team.ID] = make(map[string]*models.TeamMember)
+	return nil
+}
+
+// GetTeamByName looks up a team by its name within an organization
+func (s *Store) GetTeamByName(ctx context.Context, orgID, name string) (*models.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	team := s.teamByName(orgID, name)
+	if team == nil {
+		return nil, store.ErrNotFound
+	}
+	found := *team
+	return &found, nil
+}
+
+// ListTeams returns the teams of an organization with their members
+func (s *Store) ListTeams(ctx context.Context, orgID string) ([]models.TeamWithMembers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	teams := []models.TeamWithMembers{}
+	for _, team := range s.teams {
+		if team.OrgID != orgID {
+			continue
+		}
+
+		members := []models.TeamMember{}
+		for _, member := range s.members[team.ID] {
+			found := *member
+			found.UserName = s.users[member.UserID].Name
+			members = append(members, found)
+		}
+		// Leads first, then by name
+		sort.Slice(members, func(i, j int) bool {
+			if members[i].IsLead != members[j].IsLead {
+				return members[i].IsLead
+			}
+			return members[i].UserName < members[j].UserName
+		})
+
+		teams = append(teams, models.TeamWithMembers{Team: *team, Members: members})
+	}
+	sort.Slice(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+
+	return teams, nil
+}
+
+// SetTeamMember adds a member or updates their lead flag
+func (s *Store) SetTeamMember(ctx context.Context, teamID, userID string, isLead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.members[teamID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+
+	if member, ok := members[userID]; ok {
+		member.IsLead = isLead
+		return nil
+	}
+	members[userID] = &models.TeamMember{TeamID: teamID, UserID: userID, IsLead: isLead, CreatedAt: now()}
+	return nil
+}
+
+// RemoveTeamMember removes a member from a team
+func (s *Store) RemoveTeamMember(ctx context.Context, teamID, userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[teamID][userID]
+	if !ok {
+		return false, store.ErrNotFound
+	}
+	delete(s.members[teamID], userID)
+	return member.IsLead, nil
+}
+
+// IsTeamLead reports whether the user leads the team
+func (s *Store) IsTeamLead(ctx context.Context, userID, teamID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member, ok := s.members[teamID][userID]
+	return ok && member.IsLead, nil
+}
+
+// LeadsTeamOf reports whether the lead leads a team that any of the users belongs to
+func (s *Store) LeadsTeamOf(ctx context.Context, leadID string, userIDs ...string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, members := range s.members {
+		if lead, ok := members[leadID]; !ok || !lead.IsLead {
+			continue
+		}
+		for _, userID := range userIDs {
+			if _, ok := members[userID]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// PickTeamAssignee returns the enabled member of the team with the fewest open
+// tasks, so that work assigned to a team is spread across its members
+func (s *Store) PickTeamAssignee(ctx context.Context, teamID string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	openTasks := make(map[string]int)
+	for _, t := range s.tasks {
+		if !t.IsArchived && isOpen(t.Status) {
+			openTasks[t.AssignedTo]++
+		}
+	}
+
+	var picked *models.User
+	for userID := range s.members[teamID] {
+		user := s.users[userID]
+		if user.IsDisabled {
+			continue
+		}
+		if picked == nil || openTasks[user.ID] < openTasks[picked.ID] ||
+			(openTasks[user.ID] == openTasks[picked.ID] && user.Name < picked.Name) {
+			picked = user
+		}
+	}
+	if picked == nil {
+		return nil, store.ErrTeamHasNoMembers
+	}
+
+	found := *picked
+	return &found, nil
+}
+
+// teamByName returns the team of the organization with the name, or nil
+func (s *Store) teamByName(orgID, name string) *models.Team {
+	for _, team := range s.teams {
+		if team.OrgID == orgID && team.Name == name {
+			return team
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// errUserReferenced mirrors the foreign keys that keep referenced users from
+// being deleted
+var errUserReferenced = errors.New("user is still referenced by tasks")
+
+// CreateUser inserts a user and its roles
+func (s *Store) CreateUser(ctx context.Context, user *models.User, roles []auth.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.insertUser(user); err != nil {
+		return err
+	}
+	s.roles[user.ID] = append([]auth.Role(nil), roles...)
+	return nil
+}
+
+// insertUser inserts a user and sets its ID and timestamps
+func (s *Store) insertUser(user *models.User) error {
+	if _, ok := s.orgs[user.OrgID]; !ok {
+		return store.ErrNotFound
+	}
+	if s.userByName(user.OrgID, user.Name) != nil {
+		return store.ErrDuplicate
+	}
+
+	created := now()
+	user.ID = newID()
+	user.IsDisabled = false
+	user.CreatedAt = created
+	user.UpdatedAt = created
+
+	stored := *user
+	s.users[user.ID] = &stored
+	return nil
+}
+
+// GetUser looks up a user by ID
+func (s *Store) GetUser(ctx context.Context, userID string) (*models.UserWithRoles, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.withRoles(user), nil
+}
+
+// GetUserByName looks up a user by name within an organization
+func (s *Store) GetUserByName(ctx context.Context, orgID, name string) (*models.UserWithRoles, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.userByName(orgID, name)
+	if user == nil {
+		return nil, store.ErrNotFound
+	}
+	return s.withRoles(user), nil
+}
+
+// ListUsers returns the users of an organization
+func (s *Store) ListUsers(ctx context.Context, orgID string, limit int) ([]models.UserWithRoles, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := []models.UserWithRoles{}
+	for _, user := range s.users {
+		if user.OrgID == orgID {
+			users = append(users, *s.withRoles(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// UpdateUser updates the provided fields only
+func (s *Store) UpdateUser(ctx context.Context, userID string, update store.UserUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.Name == nil && update.Description == nil && update.IsAdmin == nil {
+		return nil
+	}
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if update.Name != nil {
+		if other := s.userByName(user.OrgID, *update.Name); other != nil && other.ID != userID {
+			return store.ErrDuplicate
+		}
+		user.Name = *update.Name
+	}
+	if update.Description != nil {
+		user.Description = *update.Description
+	}
+	if update.IsAdmin != nil {
+		user.IsAdmin = *update.IsAdmin
+	}
+	user.UpdatedAt = now()
+	return nil
+}
+
+// SetUserDisabled updates the disabled flag of a user
+func (s *Store) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.IsDisabled = disabled
+	user.UpdatedAt = now()
+	return nil
+}
+
+// RevokeTokens increments the token generation of a user
+func (s *Store) RevokeTokens(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.TokenGeneration++
+	user.UpdatedAt = now()
+	return nil
+}
+
+// SetUserRoles updates the admin flag and the roles of a user
+func (s *Store) SetUserRoles(ctx context.Context, userID string, isAdmin bool, roles []auth.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	user.IsAdmin = isAdmin
+	user.UpdatedAt = now()
+	s.roles[userID] = append([]auth.Role(nil), roles...)
+	return nil
+}
+
+// CountUserReferences counts the tasks that reference the user
+func (s *Store) CountUserReferences(ctx context.Context, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.countReferences(userID), nil
+}
+
+// countReferences counts what would keep the user from being deleted
+func (s *Store) countReferences(userID string) int {
+	count := 0
+	for _, t := range s.tasks {
+		if t.AssignedTo == userID || t.CreatedBy == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteUser transfers the user's tasks, detaches their comments and progress
+// reports and deletes the user
+func (s *Store) DeleteUser(ctx context.Context, userID, reassignTo string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return 0, store.ErrNotFound
+	}
+
+	var openTasksReassigned int64
+	if reassignTo != "" {
+		if _, ok := s.users[reassignTo]; !ok {
+			return 0, store.ErrNotFound
+		}
+
+		updated := now()
+		for _, t := range s.tasks {
+			if t.AssignedTo == userID {
+				// Open tasks go to the target's queue
+				if !t.IsArchived && isOpen(t.Status) {
+					t.UpdatedAt = updated
+					openTasksReassigned++
+				}
+				t.AssignedTo = reassignTo
+			}
+			if t.CreatedBy == userID {
+				t.CreatedBy = reassignTo
+			}
+		}
+	} else if s.countReferences(userID) > 0 {
+		return 0, errUserReferenced
+	}
+
+	// Comments and progress reports keep their text without an author
+	for _, comments := range s.comments {
+		for _, comment := range comments {
+			if comment.CreatedBy == userID {
+				comment.CreatedBy = ""
+			}
+		}
+	}
+	for _, report := range s.reports {
+		if report.createdBy == userID {
+			report.createdBy = ""
+		}
+	}
+
+	// Everything else owned by the user goes with it
+	delete(s.users, userID)
+	delete(s.roles, userID)
+	for key, id := range s.identities {
+		if id == userID {
+			delete(s.identities, key)
+		}
+	}
+	for _, members := range s.members {
+		delete(members, userID)
+	}
+	for id, key := range s.apiKeys {
+		if key.UserID == userID {
+			delete(s.apiKeys, id)
+		}
+	}
+
+	if openTasksReassigned > 0 {
+		s.watchers.Notify(reassignTo)
+	}
+	return openTasksReassigned, nil
+}
+
+// GetUserByIdentity looks up the user linked to an identity provider subject
+func (s *Store) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.UserWithRoles, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[s.identities[identity{issuer, subject}]]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return s.withRoles(user), nil
+}
+
+// LinkIdentity links an identity provider subject to a user
+func (s *Store) LinkIdentity(ctx context.Context, userID, issuer, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	return s.linkIdentity(userID, identity{issuer, subject})
+}
+
+// linkIdentity links the subject unless it is linked already
+func (s *Store) linkIdentity(userID string, id identity) error {
+	if _, ok := s.identities[id]; ok {
+		return store.ErrDuplicate
+	}
+	s.identities[id] = userID
+	return nil
+}
+
+// CreateUserWithIdentity creates a user and links the identity in one step
+func (s *Store) CreateUserWithIdentity(ctx context.Context, user *models.User, issuer, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := identity{issuer, subject}
+	if _, ok := s.identities[id]; ok {
+		return store.ErrDuplicate
+	}
+	if err := s.insertUser(user); err != nil {
+		return err
+	}
+	return s.linkIdentity(user.ID, id)
+}
+
+// userByName returns the user of the organization with the name, or nil
+func (s *Store) userByName(orgID, name string) *models.User {
+	for _, user := range s.users {
+		if user.OrgID == orgID && user.Name == name {
+			return user
+		}
+	}
+	return nil
+}
+
+// withRoles returns a copy of the user with its effective roles
+func (s *Store) withRoles(user *models.User) *models.UserWithRoles {
+	withRoles := store.WithRoles(*user, s.roles[user.ID])
+	return &withRoles
+}
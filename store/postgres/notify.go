@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// taskChangesChannel is the channel the tasks trigger notifies on; the payload
+// is the ID of the task's assignee
+const taskChangesChannel = "task_changes"
+
+// listenTaskChanges starts listening for task change notifications over one
+// shared connection, so that changes made by any server process wake the
+// watchers of the affected assignee
+func (s *Store) listenTaskChanges(databaseURL string) error {
+	listener := pq.NewListener(databaseURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Task listener error: %v", err)
+		}
+	})
+	if err := listener.Listen(taskChangesChannel); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to listen for task changes: %w", err)
+	}
+
+	s.listener = listener
+	go s.dispatchTaskChanges()
+
+	log.Println("Listening for task changes")
+	return nil
+}
+
+// dispatchTaskChanges wakes the watchers until the listener is closed
+func (s *Store) dispatchTaskChanges() {
+	for notification := range s.listener.Notify {
+		// A nil notification means the connection was re-established and
+		// notifications may have been lost, so every watcher re-checks
+		if notification == nil {
+			s.watchers.NotifyAll()
+			continue
+		}
+		s.watchers.Notify(notification.Extra)
+	}
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+// GetOrganizationByName looks up an organization by its name
+func (s *Store) GetOrganizationByName(ctx context.Context, name string) (*models.Organization, error) {
+	var org models.Organization
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, created_at, updated_at FROM organizations WHERE name = $1", name).
+		Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &org, nil
+}
+
+// EnsureOrganization finds or creates an organization
+func (s *Store) EnsureOrganization(ctx context.Context, name string) (*models.Organization, error) {
+	query := `
+		INSERT INTO organizations (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, name, created_at, updated_at`
+
+	var org models.Organization
+	err := s.db.QueryRowContext(ctx, query, name).Scan(&org.ID, &org.Name, &org.CreatedAt, &org.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
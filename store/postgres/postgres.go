@@ -0,0 +1,73 @@
+// Package postgres implements the store on PostgreSQL
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/lib/pq"
+)
+
+// Store is the PostgreSQL implementation of store.Store
+type Store struct {
+	db       *sql.DB
+	listener *pq.Listener
+	watchers store.TaskWatchers
+}
+
+var _ store.Store = (*Store)(nil)
+
+// Open connects to the database and starts listening for task changes so that
+// callers can wait for new tasks
+func Open(databaseURL string) (*Store, error) {
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{db: db}
+	if err := s.listenTaskChanges(databaseURL); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Migrate applies the pending SQL migrations
+func (s *Store) Migrate(ctx context.Context) error {
+	return database.RunMigrations(ctx, s.db, database.PostgresMigrations)
+}
+
+// Migrations returns the migrator of the database schema
+func (s *Store) Migrations() *database.Migrator {
+	return database.NewMigrator(s.db, database.PostgresMigrations)
+}
+
+// Close stops the task listener and closes the database connection
+func (s *Store) Close() error {
+	s.listener.Close()
+	return s.db.Close()
+}
+
+// WatchTasks subscribes to the task change notifications of the user
+func (s *Store) WatchTasks(userID string) (<-chan struct{}, func()) {
+	return s.watchers.Watch(userID)
+}
+
+// notFound translates sql.ErrNoRows into store.ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether the error is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
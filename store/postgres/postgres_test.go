@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	// The tests share one database; each works in organizations of its own
+	var once sync.Once
+	var st *Store
+	storetest.Run(t, func(t *testing.T) store.Store {
+		once.Do(func() {
+			var err error
+			st, err = Open(databaseURL)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if err := st.Migrate(context.Background()); err != nil {
+				t.Fatalf("Migrate: %v", err)
+			}
+		})
+		if st == nil {
+			t.Fatal("store could not be opened")
+		}
+		return st
+	})
+	if st != nil {
+		st.Close()
+	}
+}
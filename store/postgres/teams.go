@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/lib/pq"
+)
+
+// CreateTeam inserts a team
+func (s *Store) CreateTeam(ctx context.Context, team *models.Team) error {
+	query := `
+		INSERT INTO teams (org_id, name, description)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id, created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query, team.OrgID, team.Name, team.Description).Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+// GetTeamByName looks up a team by its name within an organization
+func (s *Store) GetTeamByName(ctx context.Context, orgID, name string) (*models.Team, error) {
+	var team models.Team
+	var description sql.NullString
+	query := `
+		SELECT id, org_id, name, description, created_at, updated_at
+		FROM teams
+		WHERE org_id = $1 AND name = $2`
+
+	err := s.db.QueryRowContext(ctx, query, orgID, name).Scan(&team.ID, &team.OrgID, &team.Name, &description, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	team.Description = description.String
+
+	return &team, nil
+}
+
+// ListTeams returns the teams of an organization with their members
+func (s *Store) ListTeams(ctx context.Context, orgID string) ([]models.TeamWithMembers, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, org_id, name, description, created_at, updated_at
+		FROM teams
+		WHERE org_id = $1
+		ORDER BY name ASC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.TeamWithMembers{}
+	teamIndex := make(map[string]int)
+	for rows.Next() {
+		var team models.TeamWithMembers
+		var description sql.NullString
+		if err := rows.Scan(&team.ID, &team.OrgID, &team.Name, &description, &team.CreatedAt, &team.UpdatedAt); err != nil {
+			return nil, err
+		}
+		team.Description = description.String
+		team.Members = []models.TeamMember{}
+		teamIndex[team.ID] = len(teams)
+		teams = append(teams, team)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	memberRows, err := s.db.QueryContext(ctx, `
+		SELECT m.team_id, m.user_id, u.name, m.is_lead, m.created_at
+		FROM team_members m
+		JOIN users u ON m.user_id = u.id
+		WHERE u.org_id = $1
+		ORDER BY m.is_lead DESC, u.name ASC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var member models.TeamMember
+		if err := memberRows.Scan(&member.TeamID, &member.UserID, &member.UserName, &member.IsLead, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		if i, ok := teamIndex[member.TeamID]; ok {
+			teams[i].Members = append(teams[i].Members, member)
+		}
+	}
+
+	return teams, memberRows.Err()
+}
+
+// SetTeamMember adds a member or updates their lead flag
+func (s *Store) SetTeamMember(ctx context.Context, teamID, userID string, isLead bool) error {
+	query := `
+		INSERT INTO team_members (team_id, user_id, is_lead)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_id, user_id) DO UPDATE SET is_lead = EXCLUDED.is_lead`
+
+	_, err := s.db.ExecContext(ctx, query, teamID, userID, isLead)
+	return err
+}
+
+// RemoveTeamMember removes a member from a team
+func (s *Store) RemoveTeamMember(ctx context.Context, teamID, userID string) (bool, error) {
+	var isLead bool
+	err := s.db.QueryRowContext(ctx, "DELETE FROM team_members WHERE team_id = $1 AND user_id = $2 RETURNING is_lead", teamID, userID).Scan(&isLead)
+	return isLead, notFound(err)
+}
+
+// IsTeamLead reports whether the user leads the team
+func (s *Store) IsTeamLead(ctx context.Context, userID, teamID string) (bool, error) {
+	var isLead bool
+	err := s.db.QueryRowContext(ctx, "SELECT is_lead FROM team_members WHERE team_id = $1 AND user_id = $2", teamID, userID).Scan(&isLead)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isLead, err
+}
+
+// LeadsTeamOf reports whether the lead leads a team that any of the users belongs to
+func (s *Store) LeadsTeamOf(ctx context.Context, leadID string, userIDs ...string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM team_members lead
+			JOIN team_members member ON member.team_id = lead.team_id
+			WHERE lead.user_id = $1 AND lead.is_lead AND member.user_id = ANY($2)
+		)`
+
+	var leads bool
+	err := s.db.QueryRowContext(ctx, query, leadID, pq.Array(userIDs)).Scan(&leads)
+	return leads, err
+}
+
+// PickTeamAssignee returns the enabled member of the team with the fewest open
+// tasks, so that work assigned to a team is spread across its members
+func (s *Store) PickTeamAssignee(ctx context.Context, teamID string) (*models.User, error) {
+	query := `
+		SELECT u.id, u.org_id, u.name
+		FROM team_members m
+		JOIN users u ON m.user_id = u.id
+		LEFT JOIN tasks t ON t.assigned_to = u.id
+			AND NOT t.is_archived
+			AND t.status IN ('pending', 'in_progress', 'waiting_for_user')
+		WHERE m.team_id = $1 AND NOT u.is_disabled
+		GROUP BY u.id, u.org_id, u.name
+		ORDER BY COUNT(t.id) ASC, u.name ASC
+		LIMIT 1`
+
+	var user models.User
+	err := s.db.QueryRowContext(ctx, query, teamID).Scan(&user.ID, &user.OrgID, &user.Name)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrTeamHasNoMembers
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+// CreateAPIKey inserts an API key
+func (s *Store) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	return s.db.QueryRowContext(ctx, query, key.ID, key.UserID, key.Name, key.KeyHash).Scan(&key.CreatedAt)
+}
+
+// GetAPIKey looks up an API key by ID
+func (s *Store) GetAPIKey(ctx context.Context, keyID string) (*models.APIKey, error) {
+	var key models.APIKey
+	query := `
+		SELECT id, user_id, name, key_hash, created_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE id = $1`
+
+	err := s.db.QueryRowContext(ctx, query, keyID).Scan(&key.ID, &key.UserID, &key.Name, &key.KeyHash, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &key, nil
+}
+
+// TouchAPIKey updates the last used time of an API key
+func (s *Store) TouchAPIKey(ctx context.Context, keyID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = "+currentTime+" WHERE id = $1", keyID)
+	return err
+}
+
+// RevokeAPIKey revokes an API key
+func (s *Store) RevokeAPIKey(ctx context.Context, keyID string) (time.Time, error) {
+	var revokedAt time.Time
+	err := s.db.QueryRowContext(ctx, "UPDATE api_keys SET revoked_at = "+currentTime+" WHERE id = $1 RETURNING revoked_at", keyID).Scan(&revokedAt)
+	return revokedAt, notFound(err)
+}
@@ -0,0 +1,82 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+// queryRower is implemented by *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ListComments returns the comments of a task in chronological order
+func (s *Store) ListComments(ctx context.Context, taskID string) ([]models.TaskCommentWithUser, error) {
+	query := `
+		SELECT
+			tc.id, tc.task_id, tc.created_by, tc.comment, tc.created_at,
+			u.name as created_by_name
+		FROM task_comments tc
+		LEFT JOIN users u ON tc.created_by = u.id
+		WHERE tc.task_id = $1
+		ORDER BY tc.created_at ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.TaskCommentWithUser{}
+	for rows.Next() {
+		var comment models.TaskCommentWithUser
+		var createdBy, createdByName sql.NullString
+		err := rows.Scan(
+			&comment.ID, &comment.TaskID, &createdBy,
+			&comment.Comment, &comment.CreatedAt, &createdByName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comment.CreatedBy = createdBy.String
+		comment.CreatedByName = authorName(createdByName)
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// authorName returns the name of a comment or progress report author, or the
+// deleted user placeholder once the author is gone
+func authorName(name sql.NullString) string {
+	if !name.Valid {
+		return models.DeletedUserName
+	}
+	return name.String
+}
+
+// AddComment adds a comment to a task
+func (s *Store) AddComment(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	return addComment(ctx, s.db, taskID, userID, comment)
+}
+
+// addComment inserts a comment and returns it with the commenter's name
+func addComment(ctx context.Context, q queryRower, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	query := `
+		INSERT INTO task_comments (id, task_id, created_by, comment)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, task_id, created_by, comment, created_at,
+			(SELECT name FROM users WHERE users.id = task_comments.created_by)`
+
+	var added models.TaskCommentWithUser
+	err := q.QueryRowContext(ctx, query, newID(), taskID, userID, comment).Scan(
+		&added.ID, &added.TaskID, &added.CreatedBy,
+		&added.Comment, &added.CreatedAt, &added.CreatedByName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
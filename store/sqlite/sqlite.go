@@ -0,0 +1,115 @@
+// Package sqlite implements the store on a SQLite database file, for
+// single-node deployments without a database server
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/google/uuid"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// currentTime is the SQL expression for the current time in the format of the
+// column defaults, which sorts in chronological order
+const currentTime = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+// Store is the SQLite implementation of store.Store
+type Store struct {
+	db       *sql.DB
+	watchers store.TaskWatchers
+}
+
+var _ store.Store = (*Store)(nil)
+
+// Open opens the database file of a sqlite:// URL, creating it if needed:
+// sqlite:///var/lib/tasks.db for an absolute path, sqlite://tasks.db for a
+// path relative to the working directory
+func Open(databaseURL string) (*Store, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database URL: %w", err)
+	}
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, fmt.Errorf("database URL '%s' has no file path", databaseURL)
+	}
+
+	// Foreign keys are off by default in SQLite. WAL lets other processes,
+	// such as create-admin, read the file while the server writes to it.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// All queries of the server share one connection, so they run one at a
+	// time. SQLite allows a single writer, and the store's transactions read
+	// before they write: on separate connections two of them can each hold a
+	// read lock while waiting for the other to release it, which fails with
+	// SQLITE_BUSY however long busy_timeout is. Nothing may keep the
+	// connection across a wait, or every other request stalls behind it;
+	// get_next_task waits for task changes between its queries, not inside
+	// one.
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	log.Printf("Successfully opened SQLite database %s", path)
+	return &Store{db: db}, nil
+}
+
+// Migrate applies the pending SQL migrations
+func (s *Store) Migrate(ctx context.Context) error {
+	return database.RunMigrations(ctx, s.db, database.SQLiteMigrations)
+}
+
+// Migrations returns the migrator of the database schema
+func (s *Store) Migrations() *database.Migrator {
+	return database.NewMigrator(s.db, database.SQLiteMigrations)
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// WatchTasks subscribes to the changes of the tasks assigned to the user; only
+// changes made through this process are seen
+func (s *Store) WatchTasks(userID string) (<-chan struct{}, func()) {
+	return s.watchers.Watch(userID)
+}
+
+// newID generates a row ID, SQLite has no UUID default
+func newID() string {
+	return uuid.New().String()
+}
+
+// notFound translates sql.ErrNoRows into store.ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// isUniqueViolation reports whether the error is a unique or primary key
+// constraint violation
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
@@ -0,0 +1,396 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// taskWithUsersQuery selects tasks with the names of their creator, assignee
+// and team; rows are read with scanTaskWithUsers
+const taskWithUsersQuery = `
+	SELECT
+		t.id, t.description, t.status,
+		t.created_by, t.assigned_to, t.assigned_team_id, t.result,
+		t.is_archived, t.created_at, t.updated_at,
+		t.completed_at, t.archived_at,
+		t.progress_percent, t.progress_message, t.progress_updated_at,
+		creator.name as creator_name,
+		assignee.name as assignee_name,
+		team.name as team_name
+	FROM tasks t
+	JOIN users creator ON t.created_by = creator.id
+	JOIN users assignee ON t.assigned_to = assignee.id
+	LEFT JOIN teams team ON t.assigned_team_id = team.id`
+
+// scanTaskWithUsers scans a row selected by taskWithUsersQuery
+func scanTaskWithUsers(row rowScanner) (*models.TaskWithUsers, error) {
+	var task models.TaskWithUsers
+	var createdAt, updatedAt time.Time
+	var completedAt, archivedAt sql.NullTime
+	var result, teamID, teamName sql.NullString
+	var progressPercent sql.NullInt32
+	var progressMessage sql.NullString
+	var progressUpdatedAt sql.NullTime
+
+	err := row.Scan(
+		&task.ID, &task.Description, &task.Status,
+		&task.CreatedByID, &task.AssignedToID, &teamID, &result,
+		&task.IsArchived, &createdAt, &updatedAt,
+		&completedAt, &archivedAt,
+		&progressPercent, &progressMessage, &progressUpdatedAt,
+		&task.CreatedBy, &task.AssignedTo,
+		&teamName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	task.CreatedAt = createdAt.Format("2006-01-02T15:04:05Z")
+	task.UpdatedAt = updatedAt.Format("2006-01-02T15:04:05Z")
+
+	if result.Valid {
+		task.Result = &result.String
+	}
+
+	if teamID.Valid {
+		task.AssignedTeamID = &teamID.String
+	}
+
+	if teamName.Valid {
+		task.AssignedTeam = &teamName.String
+	}
+
+	if completedAt.Valid {
+		completedAtStr := completedAt.Time.Format("2006-01-02T15:04:05Z")
+		task.CompletedAt = &completedAtStr
+	}
+
+	if archivedAt.Valid {
+		archivedAtStr := archivedAt.Time.Format("2006-01-02T15:04:05Z")
+		task.ArchivedAt = &archivedAtStr
+	}
+
+	if progressPercent.Valid {
+		task.Progress = &models.TaskProgress{
+			Percent:   int(progressPercent.Int32),
+			Message:   progressMessage.String,
+			UpdatedAt: progressUpdatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	return &task, nil
+}
+
+// taskConditions builds the WHERE clause selecting the tasks of the filter
+func taskConditions(filter store.TaskFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	// Tasks never leak across organizations
+	conditions = append(conditions, "t.org_id = "+param(filter.OrgID))
+
+	if filter.CreatedBy != "" {
+		conditions = append(conditions, "t.created_by = "+param(filter.CreatedBy))
+	}
+	if filter.AssignedTo != "" {
+		conditions = append(conditions, "t.assigned_to = "+param(filter.AssignedTo))
+	}
+	if filter.TeamID != "" {
+		teamParam := param(filter.TeamID)
+		conditions = append(conditions, fmt.Sprintf(`(t.created_by IN (SELECT user_id FROM team_members WHERE team_id = %[1]s)
+		OR t.assigned_to IN (SELECT user_id FROM team_members WHERE team_id = %[1]s)
+		OR t.assigned_team_id = %[1]s)`, teamParam))
+	}
+	if len(filter.Statuses) > 0 {
+		placeholders := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			placeholders[i] = param(status)
+		}
+		conditions = append(conditions, fmt.Sprintf("t.status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if filter.ExcludeArchived {
+		conditions = append(conditions, "NOT t.is_archived")
+	}
+
+	return "\n\tWHERE " + strings.Join(conditions, "\n\t\tAND "), args
+}
+
+// CreateTask inserts a task
+func (s *Store) CreateTask(ctx context.Context, task *models.Task) error {
+	query := `
+		INSERT INTO tasks (id, org_id, description, status, created_by, assigned_to, assigned_team_id, is_archived)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at`
+
+	err := s.db.QueryRowContext(ctx, query,
+		task.ID,
+		task.OrgID,
+		task.Description,
+		task.Status,
+		task.CreatedBy,
+		task.AssignedTo,
+		task.AssignedTeamID,
+		task.IsArchived,
+	).Scan(&task.CreatedAt, &task.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	s.watchers.Notify(task.AssignedTo)
+	return nil
+}
+
+// GetTask loads a task of the organization
+func (s *Store) GetTask(ctx context.Context, orgID, taskID string) (*models.TaskWithUsers, error) {
+	query := taskWithUsersQuery + `
+	WHERE t.id = $1 AND t.org_id = $2`
+
+	task, err := scanTaskWithUsers(s.db.QueryRowContext(ctx, query, taskID, orgID))
+	return task, notFound(err)
+}
+
+// ListTasks returns the tasks matching the filter
+func (s *Store) ListTasks(ctx context.Context, filter store.TaskFilter) ([]models.TaskWithUsers, error) {
+	where, args := taskConditions(filter)
+
+	query := taskWithUsersQuery + where + "\n\tORDER BY t.created_at ASC"
+	if filter.NewestFirst {
+		query = taskWithUsersQuery + where + "\n\tORDER BY t.created_at DESC"
+	}
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf("\n\tLIMIT $%d", len(args))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.TaskWithUsers{}
+	for rows.Next() {
+		task, err := scanTaskWithUsers(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// CountTasks counts the tasks matching the filter
+func (s *Store) CountTasks(ctx context.Context, filter store.TaskFilter) (int, error) {
+	where, args := taskConditions(filter)
+
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks t"+where, args...).Scan(&count)
+	return count, err
+}
+
+// CompleteTask marks a task as completed
+func (s *Store) CompleteTask(ctx context.Context, taskID, result string) error {
+	query := `
+		UPDATE tasks
+		SET status = $1, result = $2, completed_at = ` + currentTime + `, updated_at = ` + currentTime + `
+		WHERE id = $3
+		RETURNING assigned_to`
+
+	var assignedTo string
+	err := s.db.QueryRowContext(ctx, query, models.StatusCompleted, result, taskID).Scan(&assignedTo)
+	if err != nil {
+		return notFound(err)
+	}
+
+	s.watchers.Notify(assignedTo)
+	return nil
+}
+
+// CancelTask marks a task as cancelled, unless it was finished in the meantime
+func (s *Store) CancelTask(ctx context.Context, taskID, result string) error {
+	query := `
+		UPDATE tasks
+		SET status = $1, result = $2, updated_at = ` + currentTime + `
+		WHERE id = $3 AND status NOT IN ($4, $5)
+		RETURNING assigned_to`
+
+	var assignedTo string
+	err := s.db.QueryRowContext(ctx, query, models.StatusCancelled, result, taskID, models.StatusCompleted, models.StatusCancelled).Scan(&assignedTo)
+	if err == sql.ErrNoRows {
+		return store.ErrTaskClosed
+	}
+	if err != nil {
+		return err
+	}
+
+	s.watchers.Notify(assignedTo)
+	return nil
+}
+
+// ReassignTask assigns an open task to another user
+func (s *Store) ReassignTask(ctx context.Context, taskID, assigneeID string) error {
+	query := `
+		UPDATE tasks
+		SET assigned_to = $1, updated_at = ` + currentTime + `
+		WHERE id = $2 AND status NOT IN ($3, $4)`
+
+	res, err := s.db.ExecContext(ctx, query, assigneeID, taskID, models.StatusCompleted, models.StatusCancelled)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrTaskClosed
+	}
+
+	s.watchers.Notify(assigneeID)
+	return nil
+}
+
+// WaitForUser updates the task status and adds the comment in one transaction
+func (s *Store) WaitForUser(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var assignedTo string
+	err = tx.QueryRowContext(ctx, "UPDATE tasks SET status = $1, updated_at = "+currentTime+" WHERE id = $2 RETURNING assigned_to", models.StatusWaitingForUser, taskID).
+		Scan(&assignedTo)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	added, err := addComment(ctx, tx, taskID, userID, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.watchers.Notify(assignedTo)
+	return added, nil
+}
+
+// RespondToTask updates the task status and adds the comment in one transaction
+func (s *Store) RespondToTask(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var assignedTo string
+	err = tx.QueryRowContext(ctx, "UPDATE tasks SET status = $1, updated_at = "+currentTime+" WHERE id = $2 RETURNING assigned_to", models.StatusPending, taskID).
+		Scan(&assignedTo)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	added, err := addComment(ctx, tx, taskID, userID, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.watchers.Notify(assignedTo)
+	return added, nil
+}
+
+// ReportProgress stores the report on the task and in its history in one transaction
+func (s *Store) ReportProgress(ctx context.Context, taskID, userID string, percent int, message string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	progressMessage := sql.NullString{String: message, Valid: message != ""}
+
+	// Reporting progress means work has started, but a task waiting for user
+	// keeps waiting for the answer to its question
+	var assignedTo string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE tasks
+		SET status = CASE WHEN status = $7 THEN status ELSE $1 END,
+			progress_percent = $2, progress_message = $3,
+			progress_updated_at = `+currentTime+`, updated_at = `+currentTime+`
+		WHERE id = $4 AND status NOT IN ($5, $6)
+		RETURNING assigned_to`,
+		models.StatusInProgress, percent, progressMessage, taskID,
+		models.StatusCompleted, models.StatusCancelled, models.StatusWaitingForUser).Scan(&assignedTo)
+	if err == sql.ErrNoRows {
+		return store.ErrTaskClosed
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_progress (id, task_id, created_by, percent, message)
+		VALUES ($1, $2, $3, $4, $5)`,
+		newID(), taskID, userID, percent, progressMessage)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.watchers.Notify(assignedTo)
+	return nil
+}
+
+// ListProgress returns the progress history of a task in chronological order
+func (s *Store) ListProgress(ctx context.Context, taskID string) ([]models.TaskProgressReport, error) {
+	query := `
+		SELECT
+			tp.id, tp.task_id, tp.created_by, u.name as created_by_name,
+			tp.percent, tp.message, tp.created_at
+		FROM task_progress tp
+		LEFT JOIN users u ON tp.created_by = u.id
+		WHERE tp.task_id = $1
+		ORDER BY tp.created_at ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []models.TaskProgressReport{}
+	for rows.Next() {
+		var report models.TaskProgressReport
+		var createdBy, createdByName, message sql.NullString
+		err := rows.Scan(
+			&report.ID, &report.TaskID, &createdBy, &createdByName,
+			&report.Percent, &message, &report.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report.CreatedBy = createdBy.String
+		report.CreatedByName = authorName(createdByName)
+		report.Message = message.String
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
@@ -0,0 +1,357 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// userQuery selects users with their assigned roles as a comma separated list;
+// rows are read with scanUser and the query must end with GROUP BY u.id
+const userQuery = `
+	SELECT u.id, u.org_id, u.name, u.description, u.is_admin, u.is_disabled, u.created_at, u.updated_at,
+		u.token_generation,
+		GROUP_CONCAT(r.role, ',' ORDER BY r.role) AS roles
+	FROM users u
+	LEFT JOIN user_roles r ON r.user_id = u.id`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser scans a row selected by userQuery
+func scanUser(row rowScanner) (*models.UserWithRoles, error) {
+	var user models.User
+	var description, roles sql.NullString
+	err := row.Scan(&user.ID, &user.OrgID, &user.Name, &description, &user.IsAdmin, &user.IsDisabled,
+		&user.CreatedAt, &user.UpdatedAt, &user.TokenGeneration, &roles)
+	if err != nil {
+		return nil, err
+	}
+	user.Description = description.String
+
+	assigned := []auth.Role{}
+	if roles.String != "" {
+		for _, role := range strings.Split(roles.String, ",") {
+			assigned = append(assigned, auth.Role(role))
+		}
+	}
+
+	withRoles := store.WithRoles(user, assigned)
+	return &withRoles, nil
+}
+
+// CreateUser inserts a user and its roles
+func (s *Store) CreateUser(ctx context.Context, user *models.User, roles []auth.Role) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+
+	if err := replaceUserRoles(ctx, tx, user.ID, roles); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertUser inserts a user and sets its ID and timestamps
+func insertUser(ctx context.Context, tx *sql.Tx, user *models.User) error {
+	query := `
+		INSERT INTO users (id, org_id, name, description, is_admin)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, is_disabled, created_at, updated_at`
+
+	err := tx.QueryRowContext(ctx, query, newID(), user.OrgID, user.Name, user.Description, user.IsAdmin).
+		Scan(&user.ID, &user.IsDisabled, &user.CreatedAt, &user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+// replaceUserRoles replaces the rows in user_roles for a user
+func replaceUserRoles(ctx context.Context, tx *sql.Tx, userID string, roles []auth.Role) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	for _, role := range roles {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) VALUES ($1, $2)", userID, string(role)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUser looks up a user by ID
+func (s *Store) GetUser(ctx context.Context, userID string) (*models.UserWithRoles, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, userQuery+`
+	WHERE u.id = $1
+	GROUP BY u.id`, userID))
+	return user, notFound(err)
+}
+
+// GetUserByName looks up a user by name within an organization
+func (s *Store) GetUserByName(ctx context.Context, orgID, name string) (*models.UserWithRoles, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, userQuery+`
+	WHERE u.org_id = $1 AND u.name = $2
+	GROUP BY u.id`, orgID, name))
+	return user, notFound(err)
+}
+
+// ListUsers returns the users of an organization
+func (s *Store) ListUsers(ctx context.Context, orgID string, limit int) ([]models.UserWithRoles, error) {
+	rows, err := s.db.QueryContext(ctx, userQuery+`
+	WHERE u.org_id = $1
+	GROUP BY u.id
+	ORDER BY u.name ASC
+	LIMIT $2`, orgID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.UserWithRoles{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateUser updates the provided fields only
+func (s *Store) UpdateUser(ctx context.Context, userID string, update store.UserUpdate) error {
+	var setClauses []string
+	var args []interface{}
+	if update.Name != nil {
+		args = append(args, *update.Name)
+		setClauses = append(setClauses, fmt.Sprintf("name = $%d", len(args)))
+	}
+	if update.Description != nil {
+		args = append(args, *update.Description)
+		setClauses = append(setClauses, fmt.Sprintf("description = NULLIF($%d, '')", len(args)))
+	}
+	if update.IsAdmin != nil {
+		args = append(args, *update.IsAdmin)
+		setClauses = append(setClauses, fmt.Sprintf("is_admin = $%d", len(args)))
+	}
+	if len(setClauses) == 0 {
+		return nil
+	}
+	args = append(args, userID)
+
+	query := fmt.Sprintf(`
+		UPDATE users
+		SET %s, updated_at = %s
+		WHERE id = $%d`,
+		strings.Join(setClauses, ", "), currentTime, len(args))
+
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// SetUserDisabled updates the disabled flag of a user
+func (s *Store) SetUserDisabled(ctx context.Context, userID string, disabled bool) error {
+	query := `
+		UPDATE users
+		SET is_disabled = $1,
+			disabled_at = CASE WHEN $1 THEN ` + currentTime + ` ELSE NULL END,
+			updated_at = ` + currentTime + `
+		WHERE id = $2`
+
+	res, err := s.db.ExecContext(ctx, query, disabled, userID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// RevokeTokens increments the token generation of a user
+func (s *Store) RevokeTokens(ctx context.Context, userID string) error {
+	query := `
+		UPDATE users
+		SET token_generation = token_generation + 1, updated_at = ` + currentTime + `
+		WHERE id = $1`
+
+	res, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	return requireRow(res)
+}
+
+// SetUserRoles updates the admin flag and the roles of a user
+func (s *Store) SetUserRoles(ctx context.Context, userID string, isAdmin bool, roles []auth.Role) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "UPDATE users SET is_admin = $1, updated_at = "+currentTime+" WHERE id = $2", isAdmin, userID)
+	if err != nil {
+		return err
+	}
+	if err := requireRow(res); err != nil {
+		return err
+	}
+
+	if err := replaceUserRoles(ctx, tx, userID, roles); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountUserReferences counts the tasks that reference the user
+func (s *Store) CountUserReferences(ctx context.Context, userID string) (int, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM tasks WHERE assigned_to = $1 OR created_by = $1)`
+
+	var count int
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// DeleteUser transfers the user's tasks, detaches their comments and progress
+// reports and deletes the user in one transaction
+func (s *Store) DeleteUser(ctx context.Context, userID, reassignTo string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var openTasksReassigned int64
+	if reassignTo != "" {
+		var exists int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM users WHERE id = $1", reassignTo).Scan(&exists); err != nil {
+			return 0, notFound(err)
+		}
+
+		// Open tasks go to the target's queue
+		res, err := tx.ExecContext(ctx, `
+			UPDATE tasks
+			SET assigned_to = $1, updated_at = `+currentTime+`
+			WHERE assigned_to = $2 AND NOT is_archived AND status NOT IN ($3, $4)`,
+			reassignTo, userID, models.StatusCompleted, models.StatusCancelled)
+		if err != nil {
+			return 0, err
+		}
+		openTasksReassigned, _ = res.RowsAffected()
+
+		// Remaining references are foreign keys that must be transferred too
+		transferQueries := []string{
+			"UPDATE tasks SET assigned_to = $1 WHERE assigned_to = $2",
+			"UPDATE tasks SET created_by = $1 WHERE created_by = $2",
+		}
+		for _, query := range transferQueries {
+			if _, err := tx.ExecContext(ctx, query, reassignTo, userID); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Comments and progress reports keep their text without an author
+	for _, query := range []string{
+		"UPDATE task_comments SET created_by = NULL WHERE created_by = $1",
+		"UPDATE task_progress SET created_by = NULL WHERE created_by = $1",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := requireRow(res); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	if openTasksReassigned > 0 {
+		s.watchers.Notify(reassignTo)
+	}
+	return openTasksReassigned, nil
+}
+
+// GetUserByIdentity looks up the user linked to an identity provider subject
+func (s *Store) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.UserWithRoles, error) {
+	user, err := scanUser(s.db.QueryRowContext(ctx, userQuery+`
+	WHERE u.id = (SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2)
+	GROUP BY u.id`, issuer, subject))
+	return user, notFound(err)
+}
+
+// LinkIdentity links an identity provider subject to a user
+func (s *Store) LinkIdentity(ctx context.Context, userID, issuer, subject string) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO user_identities (id, user_id, issuer, subject) VALUES ($1, $2, $3, $4)", newID(), userID, issuer, subject)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+// CreateUserWithIdentity creates a user and links the identity in one transaction
+func (s *Store) CreateUserWithIdentity(ctx context.Context, user *models.User, issuer, subject string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO user_identities (id, user_id, issuer, subject) VALUES ($1, $2, $3, $4)", newID(), user.ID, issuer, subject)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requireRow returns store.ErrNotFound if the statement changed no rows
+func requireRow(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Package store defines how the server persists organizations, users, teams,
+// tasks, comments and API keys. The tools only talk to these interfaces, so
+// permission checks and status transitions live in the tools and behave the
+// same on every backend.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrDuplicate is returned when a name is already taken within the organization
+	ErrDuplicate = errors.New("already exists")
+
+	// ErrTaskClosed is returned when a task was completed or cancelled before
+	// an update that requires an open task
+	ErrTaskClosed = errors.New("task is completed or cancelled")
+
+	// ErrTeamHasNoMembers is returned when a task cannot be assigned to a team
+	ErrTeamHasNoMembers = errors.New("team has no active members")
+)
+
+// Store gives access to all persistent data
+type Store interface {
+	OrganizationStore
+	UserStore
+	TeamStore
+	TaskStore
+	CommentStore
+	APIKeyStore
+
+	// Migrate brings the schema up to date
+	Migrate(ctx context.Context) error
+
+	// Close releases the connections of the store
+	Close() error
+}
+
+// OrganizationStore manages organizations
+type OrganizationStore interface {
+	// GetOrganizationByName returns ErrNotFound if the organization does not exist
+	GetOrganizationByName(ctx context.Context, name string) (*models.Organization, error)
+
+	// EnsureOrganization returns the organization, creating it if it does not exist
+	EnsureOrganization(ctx context.Context, name string) (*models.Organization, error)
+}
+
+// UserUpdate holds the user fields to change; nil fields are left unchanged
+// and an empty description clears it
+type UserUpdate struct {
+	Name        *string
+	Description *string
+	IsAdmin     *bool
+}
+
+// UserStore manages users, their roles and their linked identities. Users are
+// returned with their effective roles.
+type UserStore interface {
+	// CreateUser inserts the user with the assigned roles and sets its ID and
+	// timestamps; it returns ErrDuplicate if the name is taken
+	CreateUser(ctx context.Context, user *models.User, roles []auth.Role) error
+
+	// GetUser returns a user of any organization by ID
+	GetUser(ctx context.Context, userID string) (*models.UserWithRoles, error)
+
+	// GetUserByName returns a user of the organization by name
+	GetUserByName(ctx context.Context, orgID, name string) (*models.UserWithRoles, error)
+
+	// ListUsers returns the users of the organization ordered by name
+	ListUsers(ctx context.Context, orgID string, limit int) ([]models.UserWithRoles, error)
+
+	// UpdateUser changes the given fields; it returns ErrDuplicate if the new
+	// name is taken
+	UpdateUser(ctx context.Context, userID string, update UserUpdate) error
+
+	// SetUserDisabled disables or re-enables a user
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) error
+
+	// RevokeTokens makes the tokens issued to a user until now invalid by
+	// incrementing its token generation
+	RevokeTokens(ctx context.Context, userID string) error
+
+	// SetUserRoles replaces the admin flag and the assigned roles of a user
+	SetUserRoles(ctx context.Context, userID string, isAdmin bool, roles []auth.Role) error
+
+	// CountUserReferences counts the tasks that reference the user
+	CountUserReferences(ctx context.Context, userID string) (int, error)
+
+	// DeleteUser deletes a user. Unless reassignTo is empty, the user's open
+	// tasks are reassigned and their other tasks transferred to that user
+	// first. Comments and progress reports of the user are kept without an
+	// author. It returns the number of reassigned open tasks.
+	DeleteUser(ctx context.Context, userID, reassignTo string) (int64, error)
+
+	// GetUserByIdentity returns the user linked to an identity provider subject
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.UserWithRoles, error)
+
+	// LinkIdentity links an identity provider subject to an existing user
+	LinkIdentity(ctx context.Context, userID, issuer, subject string) error
+
+	// CreateUserWithIdentity creates a user linked to an identity provider
+	// subject in one step; it returns ErrDuplicate if the name is taken
+	CreateUserWithIdentity(ctx context.Context, user *models.User, issuer, subject string) error
+}
+
+// TeamStore manages teams and their members
+type TeamStore interface {
+	// CreateTeam inserts the team and sets its ID and timestamps; it returns
+	// ErrDuplicate if the name is taken
+	CreateTeam(ctx context.Context, team *models.Team) error
+
+	// GetTeamByName returns a team of the organization by name
+	GetTeamByName(ctx context.Context, orgID, name string) (*models.Team, error)
+
+	// ListTeams returns the teams of the organization ordered by name, with
+	// their leads first and members ordered by name
+	ListTeams(ctx context.Context, orgID string) ([]models.TeamWithMembers, error)
+
+	// SetTeamMember adds a user to a team or updates their lead flag
+	SetTeamMember(ctx context.Context, teamID, userID string, isLead bool) error
+
+	// RemoveTeamMember removes a user from a team and reports whether they
+	// led it; it returns ErrNotFound if the user is not a member
+	RemoveTeamMember(ctx context.Context, teamID, userID string) (bool, error)
+
+	// IsTeamLead reports whether the user leads the team
+	IsTeamLead(ctx context.Context, userID, teamID string) (bool, error)
+
+	// LeadsTeamOf reports whether the lead leads a team that any of the users belongs to
+	LeadsTeamOf(ctx context.Context, leadID string, userIDs ...string) (bool, error)
+
+	// PickTeamAssignee returns the enabled member of the team with the fewest
+	// open tasks, or ErrTeamHasNoMembers
+	PickTeamAssignee(ctx context.Context, teamID string) (*models.User, error)
+}
+
+// TaskFilter selects tasks of an organization. Empty fields do not filter.
+type TaskFilter struct {
+	OrgID string
+	// CreatedBy selects the tasks created by the user
+	CreatedBy string
+	// AssignedTo selects the tasks assigned to the user
+	AssignedTo string
+	// TeamID selects the tasks assigned to the team or created by or assigned
+	// to one of its members
+	TeamID   string
+	Statuses []models.TaskStatus
+	// ExcludeArchived leaves out archived tasks
+	ExcludeArchived bool
+	// NewestFirst orders by creation time descending instead of ascending
+	NewestFirst bool
+	// Limit caps the number of tasks returned; zero means no limit
+	Limit int
+}
+
+// TaskStore manages tasks. Tasks are returned with the names of their creator,
+// assignee and team, and without comments.
+type TaskStore interface {
+	// CreateTask inserts the task with the ID set by the caller and sets its timestamps
+	CreateTask(ctx context.Context, task *models.Task) error
+
+	// GetTask returns a task of the organization
+	GetTask(ctx context.Context, orgID, taskID string) (*models.TaskWithUsers, error)
+
+	// ListTasks returns the tasks matching the filter
+	ListTasks(ctx context.Context, filter TaskFilter) ([]models.TaskWithUsers, error)
+
+	// CountTasks counts the tasks matching the filter, ignoring its limit
+	CountTasks(ctx context.Context, filter TaskFilter) (int, error)
+
+	// CompleteTask marks a task as completed with the result
+	CompleteTask(ctx context.Context, taskID, result string) error
+
+	// CancelTask marks an open task as cancelled, replacing its result; it
+	// returns ErrTaskClosed if the task is completed or cancelled
+	CancelTask(ctx context.Context, taskID, result string) error
+
+	// ReassignTask assigns an open task to another user, keeping its team; it
+	// returns ErrTaskClosed if the task is completed or cancelled
+	ReassignTask(ctx context.Context, taskID, assigneeID string) error
+
+	// WaitForUser sends a task to waiting_for_user and adds the comment
+	// explaining why, in one step
+	WaitForUser(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error)
+
+	// RespondToTask answers a task waiting for user: it adds the comment and
+	// moves the task back to pending, in one step
+	RespondToTask(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error)
+
+	// ReportProgress stores a progress report on an open task and in its
+	// history, moving the task to in_progress unless it is waiting for user;
+	// it returns ErrTaskClosed if the task is completed or cancelled
+	ReportProgress(ctx context.Context, taskID, userID string, percent int, message string) error
+
+	// ListProgress returns the progress history of a task in chronological
+	// order
+	ListProgress(ctx context.Context, taskID string) ([]models.TaskProgressReport, error)
+
+	// WatchTasks returns a channel that receives a value whenever a task
+	// assigned to the user is created or changed, and a function that stops
+	// watching
+	WatchTasks(userID string) (<-chan struct{}, func())
+}
+
+// CommentStore manages task comments
+type CommentStore interface {
+	// ListComments returns the comments of a task in chronological order
+	ListComments(ctx context.Context, taskID string) ([]models.TaskCommentWithUser, error)
+
+	// AddComment adds a comment to a task
+	AddComment(ctx context.Context, taskID, userID, comment string) (*models.TaskCommentWithUser, error)
+}
+
+// APIKeyStore manages API keys
+type APIKeyStore interface {
+	// CreateAPIKey inserts the key with the ID set by the caller and sets its creation time
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+
+	// GetAPIKey returns a key of any user by ID
+	GetAPIKey(ctx context.Context, keyID string) (*models.APIKey, error)
+
+	// TouchAPIKey records that the key was just used
+	TouchAPIKey(ctx context.Context, keyID string) error
+
+	// RevokeAPIKey revokes the key and returns the revocation time
+	RevokeAPIKey(ctx context.Context, keyID string) (time.Time, error)
+}
+
+// WithRoles combines a user with its effective roles, derived from the admin
+// flag and the roles assigned to the user
+func WithRoles(user models.User, roles []auth.Role) models.UserWithRoles {
+	effective := auth.EffectiveRoles(user.IsAdmin, roles)
+	names := make([]string, len(effective))
+	for i, role := range effective {
+		names[i] = string(role)
+	}
+	return models.UserWithRoles{User: user, Roles: names}
+}
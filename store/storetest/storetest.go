@@ -0,0 +1,460 @@
+// Package storetest is a conformance suite for the store backends. Every
+// backend runs it from its own tests, so that the tools behave the same on
+// all of them.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/google/uuid"
+)
+
+// wakeupTimeout bounds the wait for a watcher wakeup; PostgreSQL delivers
+// them asynchronously
+const wakeupTimeout = 5 * time.Second
+
+// Run runs the suite against the store returned by open. The store may be
+// shared by the tests: each one works in organizations of its own.
+func Run(t *testing.T, open func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, st store.Store)
+	}{
+		{"OrganizationScoping", testOrganizationScoping},
+		{"StatusTransitions", testStatusTransitions},
+		{"WaitingAndResponded", testWaitingAndResponded},
+		{"DeleteUserReassignment", testDeleteUserReassignment},
+		{"WatcherWakeups", testWatcherWakeups},
+		{"TokenRevocation", testTokenRevocation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+// newOrganization creates an organization with a unique name
+func newOrganization(t *testing.T, st store.Store) *models.Organization {
+	t.Helper()
+	org, err := st.EnsureOrganization(context.Background(), "org-"+uuid.New().String())
+	if err != nil {
+		t.Fatalf("EnsureOrganization: %v", err)
+	}
+	return org
+}
+
+// newUser creates a user of the organization
+func newUser(t *testing.T, st store.Store, orgID, name string) *models.User {
+	t.Helper()
+	user := &models.User{OrgID: orgID, Name: name}
+	if err := st.CreateUser(context.Background(), user, nil); err != nil {
+		t.Fatalf("CreateUser %s: %v", name, err)
+	}
+	return user
+}
+
+// newTask creates a pending task
+func newTask(t *testing.T, st store.Store, orgID, createdBy, assignedTo string) string {
+	t.Helper()
+	task := &models.Task{
+		ID:          uuid.New().String(),
+		OrgID:       orgID,
+		Description: "task " + uuid.New().String(),
+		Status:      models.StatusPending,
+		CreatedBy:   createdBy,
+		AssignedTo:  assignedTo,
+	}
+	if err := st.CreateTask(context.Background(), task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task.ID
+}
+
+// getTask loads a task and fails the test if it cannot
+func getTask(t *testing.T, st store.Store, orgID, taskID string) *models.TaskWithUsers {
+	t.Helper()
+	task, err := st.GetTask(context.Background(), orgID, taskID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	return task
+}
+
+// expectStatus fails the test unless the task has the status
+func expectStatus(t *testing.T, st store.Store, orgID, taskID string, status models.TaskStatus) *models.TaskWithUsers {
+	t.Helper()
+	task := getTask(t, st, orgID, taskID)
+	if task.Status != string(status) {
+		t.Fatalf("status = %s, want %s", task.Status, status)
+	}
+	return task
+}
+
+func testOrganizationScoping(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	orgA := newOrganization(t, st)
+	orgB := newOrganization(t, st)
+
+	// Names are unique per organization only
+	aliceA := newUser(t, st, orgA.ID, "alice")
+	aliceB := newUser(t, st, orgB.ID, "alice")
+	if err := st.CreateUser(ctx, &models.User{OrgID: orgA.ID, Name: "alice"}, nil); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("duplicate user name: err = %v, want ErrDuplicate", err)
+	}
+
+	user, err := st.GetUserByName(ctx, orgB.ID, "alice")
+	if err != nil {
+		t.Fatalf("GetUserByName: %v", err)
+	}
+	if user.ID != aliceB.ID {
+		t.Errorf("GetUserByName returned the user of another organization")
+	}
+	if _, err := st.GetUserByName(ctx, orgA.ID, "nobody"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("unknown user: err = %v, want ErrNotFound", err)
+	}
+
+	users, err := st.ListUsers(ctx, orgA.ID, 100)
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != aliceA.ID {
+		t.Errorf("ListUsers returned %d users, want only alice of the organization", len(users))
+	}
+
+	// Teams too
+	for _, org := range []*models.Organization{orgA, orgB} {
+		if err := st.CreateTeam(ctx, &models.Team{OrgID: org.ID, Name: "ops"}); err != nil {
+			t.Fatalf("CreateTeam: %v", err)
+		}
+	}
+	if err := st.CreateTeam(ctx, &models.Team{OrgID: orgA.ID, Name: "ops"}); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("duplicate team name: err = %v, want ErrDuplicate", err)
+	}
+	teams, err := st.ListTeams(ctx, orgA.ID)
+	if err != nil {
+		t.Fatalf("ListTeams: %v", err)
+	}
+	if len(teams) != 1 {
+		t.Errorf("ListTeams returned %d teams, want 1", len(teams))
+	}
+
+	// Tasks are only visible in their organization
+	taskA := newTask(t, st, orgA.ID, aliceA.ID, aliceA.ID)
+	newTask(t, st, orgB.ID, aliceB.ID, aliceB.ID)
+	if _, err := st.GetTask(ctx, orgB.ID, taskA); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("task of another organization: err = %v, want ErrNotFound", err)
+	}
+
+	tasks, err := st.ListTasks(ctx, store.TaskFilter{OrgID: orgA.ID})
+	if err != nil {
+		t.Fatalf("ListTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != taskA {
+		t.Errorf("ListTasks returned %d tasks, want only the task of the organization", len(tasks))
+	}
+	count, err := st.CountTasks(ctx, store.TaskFilter{OrgID: orgA.ID})
+	if err != nil {
+		t.Fatalf("CountTasks: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("CountTasks = %d, want 1", count)
+	}
+}
+
+func testStatusTransitions(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	org := newOrganization(t, st)
+	alice := newUser(t, st, org.ID, "alice")
+	bob := newUser(t, st, org.ID, "bob")
+
+	taskID := newTask(t, st, org.ID, alice.ID, bob.ID)
+	task := expectStatus(t, st, org.ID, taskID, models.StatusPending)
+	if task.CreatedBy != "alice" || task.AssignedTo != "bob" {
+		t.Errorf("created by %s, assigned to %s, want alice and bob", task.CreatedBy, task.AssignedTo)
+	}
+
+	// Reporting progress starts the work
+	if err := st.ReportProgress(ctx, taskID, bob.ID, 40, "halfway there"); err != nil {
+		t.Fatalf("ReportProgress: %v", err)
+	}
+	task = expectStatus(t, st, org.ID, taskID, models.StatusInProgress)
+	if task.Progress == nil || task.Progress.Percent != 40 || task.Progress.Message != "halfway there" {
+		t.Errorf("progress = %+v, want 40%% halfway there", task.Progress)
+	}
+	if err := st.ReportProgress(ctx, taskID, bob.ID, 70, ""); err != nil {
+		t.Fatalf("ReportProgress: %v", err)
+	}
+	reports, err := st.ListProgress(ctx, taskID)
+	if err != nil {
+		t.Fatalf("ListProgress: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Percent != 40 || reports[0].Message != "halfway there" ||
+		reports[1].Percent != 70 || reports[1].Message != "" || reports[1].CreatedByName != "bob" {
+		t.Errorf("progress history = %+v, want 40%% halfway there, then 70%% by bob", reports)
+	}
+
+	if err := st.CompleteTask(ctx, taskID, "done"); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	task = expectStatus(t, st, org.ID, taskID, models.StatusCompleted)
+	if task.Result == nil || *task.Result != "done" || task.CompletedAt == nil {
+		t.Errorf("completed task has result %v and completion time %v", task.Result, task.CompletedAt)
+	}
+
+	// A closed task stays closed
+	if err := st.CancelTask(ctx, taskID, "too late"); !errors.Is(err, store.ErrTaskClosed) {
+		t.Errorf("CancelTask on completed task: err = %v, want ErrTaskClosed", err)
+	}
+	if err := st.ReportProgress(ctx, taskID, bob.ID, 50, ""); !errors.Is(err, store.ErrTaskClosed) {
+		t.Errorf("ReportProgress on completed task: err = %v, want ErrTaskClosed", err)
+	}
+	if err := st.ReassignTask(ctx, taskID, alice.ID); !errors.Is(err, store.ErrTaskClosed) {
+		t.Errorf("ReassignTask on completed task: err = %v, want ErrTaskClosed", err)
+	}
+	expectStatus(t, st, org.ID, taskID, models.StatusCompleted)
+
+	// Open tasks can be reassigned and cancelled
+	other := newTask(t, st, org.ID, alice.ID, bob.ID)
+	if err := st.ReassignTask(ctx, other, alice.ID); err != nil {
+		t.Fatalf("ReassignTask: %v", err)
+	}
+	if task := getTask(t, st, org.ID, other); task.AssignedTo != "alice" {
+		t.Errorf("reassigned task is assigned to %s, want alice", task.AssignedTo)
+	}
+	if err := st.CancelTask(ctx, other, "obsolete"); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	task = expectStatus(t, st, org.ID, other, models.StatusCancelled)
+	if task.Result == nil || *task.Result != "obsolete" {
+		t.Errorf("cancelled task has result %v, want obsolete", task.Result)
+	}
+
+	// Status filters see the transitions
+	for status, want := range map[models.TaskStatus]int{models.StatusPending: 0, models.StatusCompleted: 1, models.StatusCancelled: 1} {
+		count, err := st.CountTasks(ctx, store.TaskFilter{OrgID: org.ID, Statuses: []models.TaskStatus{status}})
+		if err != nil {
+			t.Fatalf("CountTasks: %v", err)
+		}
+		if count != want {
+			t.Errorf("%d %s tasks, want %d", count, status, want)
+		}
+	}
+}
+
+func testWaitingAndResponded(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	org := newOrganization(t, st)
+	alice := newUser(t, st, org.ID, "alice")
+	bob := newUser(t, st, org.ID, "bob")
+	taskID := newTask(t, st, org.ID, alice.ID, bob.ID)
+
+	question, err := st.WaitForUser(ctx, taskID, bob.ID, "which database?")
+	if err != nil {
+		t.Fatalf("WaitForUser: %v", err)
+	}
+	if question.Comment != "which database?" || question.CreatedByName != "bob" {
+		t.Errorf("question = %+v", question)
+	}
+	expectStatus(t, st, org.ID, taskID, models.StatusWaitingForUser)
+
+	// Progress on a waiting task must not hide the question from its creator
+	if err := st.ReportProgress(ctx, taskID, bob.ID, 30, "still exploring"); err != nil {
+		t.Fatalf("ReportProgress on waiting task: %v", err)
+	}
+	if task := expectStatus(t, st, org.ID, taskID, models.StatusWaitingForUser); task.Progress == nil || task.Progress.Percent != 30 {
+		t.Errorf("progress on waiting task = %+v, want 30%%", task.Progress)
+	}
+
+	answer, err := st.RespondToTask(ctx, taskID, alice.ID, "staging")
+	if err != nil {
+		t.Fatalf("RespondToTask: %v", err)
+	}
+	if answer.Comment != "staging" || answer.CreatedByName != "alice" {
+		t.Errorf("answer = %+v", answer)
+	}
+	if task := expectStatus(t, st, org.ID, taskID, models.StatusPending); task.AssignedTo != "bob" {
+		t.Errorf("answered task is assigned to %s, want bob", task.AssignedTo)
+	}
+
+	comments, err := st.ListComments(ctx, taskID)
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if len(comments) != 2 || comments[0].ID != question.ID || comments[1].ID != answer.ID {
+		t.Errorf("comments = %+v, want the question and then the answer", comments)
+	}
+}
+
+func testDeleteUserReassignment(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	org := newOrganization(t, st)
+	alice := newUser(t, st, org.ID, "alice")
+	bob := newUser(t, st, org.ID, "bob")
+	carol := newUser(t, st, org.ID, "carol")
+
+	open := newTask(t, st, org.ID, alice.ID, bob.ID)
+	if err := st.ReportProgress(ctx, open, bob.ID, 20, "started"); err != nil {
+		t.Fatalf("ReportProgress: %v", err)
+	}
+	waiting := newTask(t, st, org.ID, alice.ID, bob.ID)
+	if _, err := st.WaitForUser(ctx, waiting, bob.ID, "question"); err != nil {
+		t.Fatalf("WaitForUser: %v", err)
+	}
+	closed := newTask(t, st, org.ID, alice.ID, bob.ID)
+	if err := st.CompleteTask(ctx, closed, "done"); err != nil {
+		t.Fatalf("CompleteTask: %v", err)
+	}
+	created := newTask(t, st, org.ID, bob.ID, alice.ID)
+
+	if _, err := st.DeleteUser(ctx, bob.ID, ""); err == nil {
+		t.Fatal("DeleteUser without reassignment deleted a user with tasks")
+	}
+	if _, err := st.DeleteUser(ctx, bob.ID, uuid.New().String()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteUser reassigning to an unknown user: err = %v, want ErrNotFound", err)
+	}
+
+	reassigned, err := st.DeleteUser(ctx, bob.ID, carol.ID)
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if reassigned != 2 {
+		t.Errorf("%d open tasks reassigned, want 2", reassigned)
+	}
+
+	if _, err := st.GetUser(ctx, bob.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("deleted user: err = %v, want ErrNotFound", err)
+	}
+	for _, taskID := range []string{open, waiting, closed} {
+		if task := getTask(t, st, org.ID, taskID); task.AssignedTo != "carol" {
+			t.Errorf("task %s is assigned to %s, want carol", taskID, task.AssignedTo)
+		}
+	}
+	// Reassignment keeps the status
+	expectStatus(t, st, org.ID, waiting, models.StatusWaitingForUser)
+	expectStatus(t, st, org.ID, closed, models.StatusCompleted)
+	if task := getTask(t, st, org.ID, created); task.CreatedBy != "carol" {
+		t.Errorf("task created by the deleted user is now created by %s, want carol", task.CreatedBy)
+	}
+	comments, err := st.ListComments(ctx, waiting)
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	// Comments and progress reports stay without crediting anyone else
+	if len(comments) != 1 || comments[0].CreatedBy != "" || comments[0].CreatedByName != models.DeletedUserName {
+		t.Errorf("comments = %+v, want the comment kept by a deleted user", comments)
+	}
+	reports, err := st.ListProgress(ctx, open)
+	if err != nil {
+		t.Fatalf("ListProgress: %v", err)
+	}
+	if len(reports) != 1 || reports[0].CreatedBy != "" || reports[0].CreatedByName != models.DeletedUserName {
+		t.Errorf("progress = %+v, want the report kept by a deleted user", reports)
+	}
+
+	// Users without tasks are deleted without reassignment, even if they
+	// commented
+	dave := newUser(t, st, org.ID, "dave")
+	if _, err := st.AddComment(ctx, open, dave.ID, "drive-by"); err != nil {
+		t.Fatalf("AddComment: %v", err)
+	}
+	if _, err := st.DeleteUser(ctx, dave.ID, ""); err != nil {
+		t.Errorf("DeleteUser of a user without tasks: %v", err)
+	}
+	if _, err := st.DeleteUser(ctx, dave.ID, ""); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("DeleteUser of a deleted user: err = %v, want ErrNotFound", err)
+	}
+}
+
+func testWatcherWakeups(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	org := newOrganization(t, st)
+	alice := newUser(t, st, org.ID, "alice")
+	bob := newUser(t, st, org.ID, "bob")
+	carol := newUser(t, st, org.ID, "carol")
+
+	wakeups, unwatch := st.WatchTasks(bob.ID)
+	defer unwatch()
+
+	expectWakeup := func(what string) {
+		t.Helper()
+		select {
+		case <-wakeups:
+		case <-time.After(wakeupTimeout):
+			t.Fatalf("no wakeup after %s", what)
+		}
+		// Backends may wake several times for one change
+		for {
+			select {
+			case <-wakeups:
+			case <-time.After(50 * time.Millisecond):
+				return
+			}
+		}
+	}
+
+	taskID := newTask(t, st, org.ID, alice.ID, bob.ID)
+	expectWakeup("a task was assigned")
+
+	if _, err := st.WaitForUser(ctx, taskID, bob.ID, "question"); err != nil {
+		t.Fatalf("WaitForUser: %v", err)
+	}
+	expectWakeup("the task started waiting")
+
+	if _, err := st.RespondToTask(ctx, taskID, alice.ID, "answer"); err != nil {
+		t.Fatalf("RespondToTask: %v", err)
+	}
+	expectWakeup("the task was answered")
+
+	other := newTask(t, st, org.ID, alice.ID, alice.ID)
+	if err := st.ReassignTask(ctx, other, bob.ID); err != nil {
+		t.Fatalf("ReassignTask: %v", err)
+	}
+	expectWakeup("a task was reassigned")
+
+	newTask(t, st, org.ID, alice.ID, carol.ID)
+	if _, err := st.DeleteUser(ctx, carol.ID, bob.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	expectWakeup("the tasks of a deleted user were reassigned")
+
+	// Changes to the tasks of other users do not wake the watcher
+	newTask(t, st, org.ID, bob.ID, alice.ID)
+	select {
+	case <-wakeups:
+		t.Error("woken by a task assigned to another user")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func testTokenRevocation(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	org := newOrganization(t, st)
+	alice := newUser(t, st, org.ID, "alice")
+
+	user, err := st.GetUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	generation := user.TokenGeneration
+
+	if err := st.RevokeTokens(ctx, alice.ID); err != nil {
+		t.Fatalf("RevokeTokens: %v", err)
+	}
+	user, err = st.GetUser(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if user.TokenGeneration != generation+1 {
+		t.Errorf("token generation = %d after revoking, want %d", user.TokenGeneration, generation+1)
+	}
+
+	if err := st.RevokeTokens(ctx, uuid.New().String()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("RevokeTokens of unknown user: err = %v, want ErrNotFound", err)
+	}
+}
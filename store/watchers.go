@@ -0,0 +1,66 @@
+package store
+
+import "sync"
+
+// TaskWatchers wakes the callers waiting for tasks of a user. Backends that
+// run in the server process notify it whenever they create or change a task.
+type TaskWatchers struct {
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+// Watch returns a channel that receives a value whenever the user is notified,
+// and a function that stops watching
+func (w *TaskWatchers) Watch(userID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = make(map[string]map[chan struct{}]struct{})
+	}
+	if w.waiters[userID] == nil {
+		w.waiters[userID] = make(map[chan struct{}]struct{})
+	}
+	w.waiters[userID][ch] = struct{}{}
+	w.mu.Unlock()
+
+	unwatch := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.waiters[userID], ch)
+		if len(w.waiters[userID]) == 0 {
+			delete(w.waiters, userID)
+		}
+	}
+
+	return ch, unwatch
+}
+
+// Notify wakes the callers watching the user
+func (w *TaskWatchers) Notify(userID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for ch := range w.waiters[userID] {
+		// Watchers only need to know that something changed
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// NotifyAll wakes every watcher, for when notifications may have been lost
+func (w *TaskWatchers) NotifyAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, channels := range w.waiters {
+		for ch := range channels {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterAddTeamMemberTool registers the add_team_member tool
+func RegisterAddTeamMemberTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("add_team_member",
+		mcp.WithDescription("Add a user to a team or change whether they lead it (admin only)"),
+		mcp.WithString("team_name",
+			mcp.Required(),
+			mcp.Description("Name of the team"),
+		),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the member"),
+		),
+		mcp.WithBoolean("is_lead",
+			mcp.Description("Whether the member leads the team and can supervise its tasks (default: false)"),
+		),
+		mcp.WithOutputSchema[models.TeamMembershipResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		teamName, err := request.RequireString("team_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "team_name is required"), nil
+		}
+		userName, err := request.RequireString("user_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+		isLead := request.GetBool("is_lead", false)
+
+		team, err := st.GetTeamByName(ctx, claims.OrgID, teamName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("team '%s' does not exist", teamName)), nil
+			}
+			log.Printf("Error finding team by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName)), nil
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		if err := st.SetTeamMember(ctx, team.ID, user.ID, isLead); err != nil {
+			log.Printf("Error adding team member: %v", err)
+			return toolError(CodeInternal, "failed to add team member"), nil
+		}
+
+		result := models.TeamMembershipResponse{
+			TeamID:   team.ID,
+			TeamName: team.Name,
+			UserID:   user.ID,
+			UserName: userName,
+			IsLead:   isLead,
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("%s added to team %s", userName, team.Name)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("add_team_member tool registered")
+	return nil
+}
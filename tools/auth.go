@@ -0,0 +1,239 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errAuthRequired is the error message shared by all tools for unauthenticated calls
+const errAuthRequired = "authorization required: provide a Bearer token in the Authorization header or an API key in the X-API-Key header"
+
+// authHandlerFunc is a tool handler that receives the caller's validated claims
+type authHandlerFunc func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error)
+
+// resourceHandlerFunc is a resource handler that receives the caller's validated claims
+type resourceHandlerFunc func(ctx context.Context, request mcp.ReadResourceRequest, claims *auth.Claims) ([]mcp.ResourceContents, error)
+
+// promptHandlerFunc is a prompt handler that receives the caller's validated claims
+type promptHandlerFunc func(ctx context.Context, request mcp.GetPromptRequest, claims *auth.Claims) (*mcp.GetPromptResult, error)
+
+// AuthMiddleware validates the caller's credentials once per tool call and
+// stores the claims in the request context
+func AuthMiddleware(jwtManager *auth.JWTManager, st store.Store) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			claims, err := authenticate(ctx, request.Header, jwtManager, st)
+			if err != nil {
+				return errorResult(err, CodeUnauthenticated), nil
+			}
+			return next(auth.WithClaims(ctx, claims), request)
+		}
+	}
+}
+
+// authenticate validates the caller's credential. It is taken from the
+// X-API-Key header, the Authorization header, the session context for
+// transports without headers (stdio), or the credential bound to a stateful
+// HTTP session, and may be a JWT or an API key. Callers set with WithCaller
+// were authenticated already.
+func authenticate(ctx context.Context, header http.Header, jwtManager *auth.JWTManager, st store.Store) (*auth.Claims, error) {
+	if claims, ok := ctx.Value(callerKey{}).(*auth.Claims); ok {
+		return claims, nil
+	}
+
+	credential := credentialFromHeader(header)
+	if credential == "" {
+		credential = auth.TokenFromContext(ctx)
+	}
+	if credential == "" {
+		credential = credentialFromSession(ctx)
+	}
+	if credential == "" {
+		return nil, newError(CodeUnauthenticated, errAuthRequired)
+	}
+
+	var claims *auth.Claims
+	var err error
+	if auth.IsAPIKey(credential) {
+		claims, err = validateAPIKey(ctx, st, credential)
+		if err != nil {
+			return nil, unauthenticated(err)
+		}
+	} else {
+		claims, err = jwtManager.ValidateToken(credential)
+		if err != nil {
+			return nil, newError(CodeUnauthenticated, fmt.Sprintf("invalid token: %v", err))
+		}
+	}
+
+	if err := refreshClaims(ctx, st, claims); err != nil {
+		return nil, unauthenticated(err)
+	}
+
+	return claims, nil
+}
+
+// unauthenticated gives an error of authentication the unauthenticated code
+// unless it has one, such as a database error
+func unauthenticated(err error) error {
+	return newError(codeOf(err, CodeUnauthenticated), err.Error())
+}
+
+// callerKey is the context key of a caller authenticated outside of MCP
+type callerKey struct{}
+
+// Authenticate validates the credential in the headers of a request that does
+// not go through MCP, such as a REST API call, and returns the caller's claims
+func Authenticate(ctx context.Context, header http.Header, jwtManager *auth.JWTManager, st store.Store) (*auth.Claims, error) {
+	if credentialFromHeader(header) == "" {
+		return nil, newError(CodeUnauthenticated, errAuthRequired)
+	}
+	return authenticate(ctx, header, jwtManager, st)
+}
+
+// WithCaller returns a context for calling tools and resources in-process on
+// behalf of a caller already authenticated with Authenticate, so that the
+// credential is not validated a second time
+func WithCaller(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, callerKey{}, claims)
+}
+
+// authenticated adapts a handler that needs the caller's claims
+func authenticated(handler authHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		claims, ok := auth.ClaimsFromContext(ctx)
+		if !ok {
+			return toolError(CodeUnauthenticated, errAuthRequired), nil
+		}
+		return handler(ctx, request, claims)
+	}
+}
+
+// requirePermission adapts a handler that may only be called by users whose
+// roles grant the permission
+func requirePermission(permission auth.Permission, handler authHandlerFunc) server.ToolHandlerFunc {
+	return authenticated(func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		if err := auth.Authorize(claims, permission); err != nil {
+			return errorResult(err, CodePermissionDenied), nil
+		}
+		return handler(ctx, request, claims)
+	})
+}
+
+// resourceWithPermission adapts a resource handler that may only be read by
+// users whose roles grant the permission. Resource reads do not pass through
+// the tool middleware, so the caller is authenticated here.
+func resourceWithPermission(jwtManager *auth.JWTManager, st store.Store, permission auth.Permission, handler resourceHandlerFunc) server.ResourceTemplateHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		claims, err := authenticate(ctx, request.Header, jwtManager, st)
+		if err != nil {
+			return nil, recordError(ctx, err)
+		}
+		if err := auth.Authorize(claims, permission); err != nil {
+			return nil, recordError(ctx, err)
+		}
+		contents, err := handler(auth.WithClaims(ctx, claims), request, claims)
+		if err != nil {
+			return nil, recordError(ctx, err)
+		}
+		return contents, nil
+	}
+}
+
+// promptWithPermission adapts a prompt handler that may only be used by users
+// whose roles grant the permission
+func promptWithPermission(jwtManager *auth.JWTManager, st store.Store, permission auth.Permission, handler promptHandlerFunc) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		claims, err := authenticate(ctx, request.Header, jwtManager, st)
+		if err != nil {
+			return nil, recordError(ctx, err)
+		}
+		if err := auth.Authorize(claims, permission); err != nil {
+			return nil, recordError(ctx, err)
+		}
+		result, err := handler(auth.WithClaims(ctx, claims), request, claims)
+		if err != nil {
+			return nil, recordError(ctx, err)
+		}
+		return result, nil
+	}
+}
+
+// validateAPIKey checks an API key against its stored hash and returns claims
+// for the key's owner; the owner's organization is loaded by refreshClaims
+func validateAPIKey(ctx context.Context, st store.Store, key string) (*auth.Claims, error) {
+	keyID, secret, err := auth.ParseAPIKey(key)
+	if err != nil || !isValidUUID(keyID) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	apiKey, err := st.GetAPIKey(ctx, keyID)
+	if err == store.ErrNotFound {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		log.Printf("Error looking up API key: %v", err)
+		return nil, errDatabase
+	}
+
+	if err := auth.VerifyAPIKeySecret(apiKey.KeyHash, secret); err != nil {
+		return nil, err
+	}
+
+	if apiKey.RevokedAt.Valid {
+		return nil, errors.New("API key has been revoked")
+	}
+
+	// Track usage so that stale keys can be spotted and revoked
+	if err := st.TouchAPIKey(ctx, keyID); err != nil {
+		log.Printf("Error updating API key last used time: %v", err)
+	}
+
+	return auth.NewAPIKeyClaims(apiKey.UserID, "", keyID, apiKey.CreatedAt), nil
+}
+
+// refreshClaims rejects callers whose user was deleted or disabled after the
+// credential was issued or whose token was revoked, and loads the
+// organization, admin flag and roles from the store so privilege changes take
+// effect without issuing a new token
+func refreshClaims(ctx context.Context, st store.Store, claims *auth.Claims) error {
+	if !isValidUUID(claims.UserID) {
+		return errors.New("invalid user ID in token")
+	}
+
+	user, err := st.GetUser(ctx, claims.UserID)
+	if err == store.ErrNotFound {
+		return errors.New("user no longer exists")
+	}
+	if err != nil {
+		log.Printf("Error checking token user: %v", err)
+		return errDatabase
+	}
+
+	if user.IsDisabled {
+		return errors.New("user is disabled")
+	}
+
+	// API keys are revoked one by one
+	if claims.APIKeyID == "" && claims.TokenGeneration < user.TokenGeneration {
+		return errors.New("token has been revoked")
+	}
+
+	// Tokens issued before organizations existed carry no org_id
+	if claims.OrgID != "" && claims.OrgID != user.OrgID {
+		return errors.New("token organization does not match user")
+	}
+
+	claims.OrgID = user.OrgID
+	claims.IsAdmin = user.IsAdmin
+	claims.Roles = toRoles(user.Roles)
+	return nil
+}
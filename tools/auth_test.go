@@ -0,0 +1,329 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestRevokedTokens(t *testing.T) {
+	s := newTestServer(t, RegisterGetTokenInfoTool)
+	alice, token := s.addUser(t, "alice")
+
+	_, apiKey := s.addAPIKey(t, alice.ID)
+
+	if result := s.callTool(t, token, "get_token_info", nil); result.IsError {
+		t.Fatalf("token rejected before revocation: %v", result.Content)
+	}
+
+	if err := s.st.RevokeTokens(context.Background(), alice.ID); err != nil {
+		t.Fatalf("RevokeTokens: %v", err)
+	}
+	// Issue a new token right away, like create-admin issue-token
+	user, err := s.st.GetUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	reissued, err := s.jwtManager.GenerateToken(alice.ID, s.orgID, false, user.TokenGeneration)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	result := s.callTool(t, token, "get_token_info", nil)
+	if !result.IsError || ResultErrorCode(result) != CodeUnauthenticated {
+		t.Errorf("revoked token: error = %v, code = %s, want unauthenticated", result.IsError, ResultErrorCode(result))
+	}
+	if result := s.callTool(t, reissued, "get_token_info", nil); result.IsError {
+		t.Errorf("token issued right after the revocation rejected: %v", result.Content)
+	}
+	if result := s.callTool(t, apiKey, "get_token_info", nil); result.IsError {
+		t.Errorf("API key rejected after revoking tokens: %v", result.Content)
+	}
+}
+
+func TestAPIKeyHeader(t *testing.T) {
+	s := newTestServer(t, RegisterGetTokenInfoTool, RegisterCompleteTaskTool)
+	url := s.serveHTTP(t, server.WithStateLess(true))
+	alice, _ := s.addUser(t, "alice")
+	aliceTask := s.addTask(t, alice.ID, alice.ID, models.StatusPending)
+	keyID, apiKey := s.addAPIKey(t, alice.ID)
+	revokedID, revokedKey := s.addAPIKey(t, alice.ID)
+	if _, err := s.st.RevokeAPIKey(context.Background(), revokedID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	forgedKey, _, err := auth.GenerateAPIKey(keyID)
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+
+	// A user of another organization with a key of their own
+	other, err := s.st.EnsureOrganization(context.Background(), "other")
+	if err != nil {
+		t.Fatalf("EnsureOrganization: %v", err)
+	}
+	mallory := models.User{OrgID: other.ID, Name: "mallory"}
+	if err := s.st.CreateUser(context.Background(), &mallory, nil); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	_, foreignKey := s.addAPIKey(t, mallory.ID)
+
+	header := func(key string) http.Header {
+		return http.Header{"X-Api-Key": {key}}
+	}
+
+	var info models.TokenInfoResponse
+	decodeResult(t, callToolHTTP(t, url, header(apiKey), "get_token_info", nil), &info)
+	if info.TokenInfo.UserID != alice.ID || info.TokenInfo.OrgID != s.orgID || info.TokenInfo.APIKeyID != keyID {
+		t.Errorf("API key authenticated as %+v, want alice with key %s", info.TokenInfo, keyID)
+	}
+
+	for name, key := range map[string]string{"revoked": revokedKey, "forged": forgedKey} {
+		result := callToolHTTP(t, url, header(key), "get_token_info", nil)
+		if !result.IsError || ResultErrorCode(result) != CodeUnauthenticated {
+			t.Errorf("%s key: error = %v, code = %s, want unauthenticated", name, result.IsError, ResultErrorCode(result))
+		}
+	}
+
+	// A key only reaches the organization of its owner
+	decodeResult(t, callToolHTTP(t, url, header(foreignKey), "get_token_info", nil), &info)
+	if info.TokenInfo.UserID != mallory.ID || info.TokenInfo.OrgID != other.ID {
+		t.Errorf("foreign key authenticated as %+v, want mallory of the other organization", info.TokenInfo)
+	}
+	result := callToolHTTP(t, url, header(foreignKey), "complete_task", map[string]any{"id": aliceTask, "result": "done"})
+	if !result.IsError || ResultErrorCode(result) != CodeNotFound {
+		t.Errorf("foreign key completing a task: error = %v, code = %s, want not_found", result.IsError, ResultErrorCode(result))
+	}
+	task, err := s.st.GetTask(context.Background(), s.orgID, aliceTask)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.Status != string(models.StatusPending) {
+		t.Errorf("task status = %s after a foreign key completed it, want pending", task.Status)
+	}
+}
+
+func TestPermissionMatrix(t *testing.T) {
+	s := newTestServer(t,
+		RegisterListUsersTool, RegisterCreateTeamTool, RegisterListCreatedTasksTool, RegisterCreateTaskTool,
+		RegisterGetNextTaskTool, RegisterCompleteTaskTool, RegisterCreateAPIKeyTool)
+	other, _ := s.addUser(t, "other")
+
+	callers := map[string]string{}
+	_, callers["admin"] = s.addAdmin(t, "admin")
+	for _, role := range []auth.Role{auth.RoleManager, auth.RoleAgent, auth.RoleViewer} {
+		_, callers[string(role)] = s.addUser(t, "a-"+string(role), role)
+	}
+
+	// Each permission is exercised by a call that needs it, and granted to the
+	// roles of the matrix in the README
+	permissions := []struct {
+		permission auth.Permission
+		granted    []string
+		call       func(token string) *mcp.CallToolResult
+	}{
+		{auth.PermUsersRead, []string{"admin", "manager", "agent", "viewer"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "list_users", nil)
+		}},
+		{auth.PermUsersManage, []string{"admin"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "create_team", map[string]any{"name": "team-" + uuid.New().String()})
+		}},
+		{auth.PermTasksRead, []string{"admin", "manager", "agent", "viewer"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "list_created_tasks", nil)
+		}},
+		{auth.PermTasksReadAll, []string{"admin", "manager"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "list_created_tasks", map[string]any{"user_name": "other"})
+		}},
+		{auth.PermTasksCreate, []string{"admin", "manager", "agent"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "create_task", map[string]any{"description": "task", "assigned_to": "other"})
+		}},
+		{auth.PermTasksWork, []string{"admin", "manager", "agent"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "get_next_task", nil)
+		}},
+		{auth.PermTasksManageAll, []string{"admin"}, func(token string) *mcp.CallToolResult {
+			taskID := s.addTask(t, other.ID, other.ID, models.StatusPending)
+			return s.callTool(t, token, "complete_task", map[string]any{"id": taskID, "result": "done"})
+		}},
+		{auth.PermAPIKeysOwn, []string{"admin", "manager", "agent"}, func(token string) *mcp.CallToolResult {
+			return s.callTool(t, token, "create_api_key", map[string]any{"name": "key"})
+		}},
+	}
+
+	for _, p := range permissions {
+		for role, token := range callers {
+			result := p.call(token)
+			granted := slices.Contains(p.granted, role)
+			if granted && result.IsError {
+				t.Errorf("%s with role %s: failed: %v", p.permission, role, result.Content)
+			}
+			if !granted && ResultErrorCode(result) != CodePermissionDenied {
+				t.Errorf("%s with role %s: error = %v, code = %s, want permission_denied", p.permission, role, result.IsError, ResultErrorCode(result))
+			}
+		}
+	}
+}
+
+// stdioClient talks to the MCP server over the stdio transport
+type stdioClient struct {
+	stdin  *io.PipeWriter
+	stdout *bufio.Scanner
+	nextID int
+}
+
+// serveStdio serves the MCP server over stdio with the configured token, like
+// main does for --token and MCP_AUTH_TOKEN, and initializes the session
+func (s *testServer) serveStdio(t *testing.T, token string) *stdioClient {
+	t.Helper()
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+
+	stdio := server.NewStdioServer(s.mcp)
+	stdio.SetErrorLogger(log.New(io.Discard, "", 0))
+	stdio.SetContextFunc(func(ctx context.Context) context.Context {
+		return auth.WithToken(ctx, token)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go stdio.Listen(ctx, stdinReader, stdoutWriter)
+	t.Cleanup(func() {
+		cancel()
+		stdinWriter.Close()
+		stdoutWriter.Close()
+	})
+
+	client := &stdioClient{stdin: stdinWriter, stdout: bufio.NewScanner(stdoutReader)}
+	client.send(t, string(mcp.MethodInitialize), mcp.InitializeParams{
+		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+		ClientInfo:      mcp.Implementation{Name: "test", Version: "0.0.0"},
+	})
+	return client
+}
+
+// send sends a request and returns the result of its response
+func (c *stdioClient) send(t *testing.T, method string, params any) json.RawMessage {
+	t.Helper()
+	c.nextID++
+	message, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": c.nextID, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	if _, err := c.stdin.Write(append(message, '\n')); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+
+	// Skip notifications until the response arrives
+	for c.stdout.Scan() {
+		var response struct {
+			ID     *int            `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(c.stdout.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if response.ID != nil && *response.ID == c.nextID {
+			return response.Result
+		}
+	}
+	t.Fatalf("%s: no response: %v", method, c.stdout.Err())
+	return nil
+}
+
+// caller returns the user a tool call of the session runs as, or "" if it was
+// rejected as unauthenticated
+func (c *stdioClient) caller(t *testing.T) string {
+	t.Helper()
+	data := c.send(t, string(mcp.MethodToolsCall), mcp.CallToolParams{Name: "get_token_info"})
+	result, err := mcp.ParseCallToolResult(&data)
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if result.IsError {
+		if code := ResultErrorCode(result); code != CodeUnauthenticated {
+			t.Fatalf("get_token_info: code = %s, want unauthenticated", code)
+		}
+		return ""
+	}
+	var info models.TokenInfoResponse
+	decodeResult(t, result, &info)
+	return info.TokenInfo.UserName
+}
+
+func TestStdioToken(t *testing.T) {
+	// Every call of the session runs as the user of the configured token,
+	// which may be a JWT or an API key. mcp-go registers every stdio session
+	// under the same ID, so each session gets a server of its own.
+	tests := []struct {
+		name  string
+		token func(s *testServer) string
+		want  string
+	}{
+		{"JWT", func(s *testServer) string {
+			_, token := s.addUser(t, "alice")
+			return token
+		}, "alice"},
+		{"API key", func(s *testServer) string {
+			bob, _ := s.addUser(t, "bob")
+			_, key := s.addAPIKey(t, bob.ID)
+			return key
+		}, "bob"},
+		{"no token", func(s *testServer) string { return "" }, ""},
+	}
+	for _, tt := range tests {
+		s := newTestServer(t, RegisterGetTokenInfoTool)
+		if caller := s.serveStdio(t, tt.token(s)).caller(t); caller != tt.want {
+			t.Errorf("%s: stdio session runs as %q, want %q", tt.name, caller, tt.want)
+		}
+	}
+
+	// The token is validated on every call, not once per session
+	s := newTestServer(t, RegisterGetTokenInfoTool)
+	alice, token := s.addUser(t, "alice")
+	session := s.serveStdio(t, token)
+	if caller := session.caller(t); caller != "alice" {
+		t.Fatalf("stdio session runs as %q, want alice", caller)
+	}
+	if err := s.st.RevokeTokens(context.Background(), alice.ID); err != nil {
+		t.Fatalf("RevokeTokens: %v", err)
+	}
+	if caller := session.caller(t); caller != "" {
+		t.Errorf("stdio session with a revoked token runs as %q, want unauthenticated", caller)
+	}
+}
+
+func TestCredentialPrecedence(t *testing.T) {
+	s := newTestServer(t, RegisterGetTokenInfoTool)
+	_, aliceToken := s.addUser(t, "alice")
+	bob, bobToken := s.addUser(t, "bob")
+	_, bobKey := s.addAPIKey(t, bob.ID)
+
+	// A server whose context carries a configured token, as on stdio
+	url := s.serveHTTP(t, server.WithStateLess(true), server.WithHTTPContextFunc(func(ctx context.Context, r *http.Request) context.Context {
+		return auth.WithToken(ctx, aliceToken)
+	}))
+
+	// Credentials sent with the request take precedence over the configured
+	// token, and an API key over a bearer token
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"configured token", nil, "alice"},
+		{"bearer token", http.Header{"Authorization": {"Bearer " + bobToken}}, "bob"},
+		{"API key", http.Header{"X-Api-Key": {bobKey}, "Authorization": {"Bearer " + aliceToken}}, "bob"},
+	}
+	for _, tt := range tests {
+		if caller := callerOf(t, url, tt.header); caller != tt.want {
+			t.Errorf("%s: runs as %q, want %s", tt.name, caller, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// confirmableTools are the destructive tools that ask the human to confirm
+// through MCP elicitation before they take effect
+var confirmableTools = map[string]bool{
+	"cancel_task": true,
+	"delete_user": true,
+}
+
+// confirmationRequired lists the tools that fail unless the human confirmed
+// them; other confirmable tools only ask when the client supports elicitation
+var confirmationRequired = map[string]bool{}
+
+// RequireConfirmation makes the tools refuse to run when the client cannot ask
+// the human for confirmation. It must be called before the server starts.
+func RequireConfirmation(toolNames []string) error {
+	for _, name := range toolNames {
+		if !confirmableTools[name] {
+			return fmt.Errorf("tool '%s' does not support confirmation", name)
+		}
+		confirmationRequired[name] = true
+	}
+	return nil
+}
+
+// confirmedKey is the context key of operations confirmed up front
+type confirmedKey struct{}
+
+// WithConfirmation returns a context in which confirmable tools proceed
+// without asking, for in-process callers such as the REST API whose client
+// confirmed the operation with the request
+func WithConfirmation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, confirmedKey{}, true)
+}
+
+// confirm asks the human behind the client to confirm a destructive operation.
+// It returns nil when the operation may proceed: it was confirmed up front, the
+// human accepted, or the client does not support elicitation and the tool does
+// not require it.
+func confirm(ctx context.Context, toolName, message string) error {
+	if confirmed, _ := ctx.Value(confirmedKey{}).(bool); confirmed {
+		return nil
+	}
+
+	session := server.ClientSessionFromContext(ctx)
+	mcpServer := server.ServerFromContext(ctx)
+	if session == nil || mcpServer == nil || !supportsElicitation(session) {
+		if confirmationRequired[toolName] {
+			return newError(CodeConfirmationRequired, fmt.Sprintf("%s requires confirmation, but the client does not support elicitation", toolName))
+		}
+		return nil
+	}
+
+	result, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: message,
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"confirm": map[string]any{
+						"type":        "boolean",
+						"title":       "Confirm",
+						"description": "Check to proceed",
+					},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error requesting confirmation for %s: %v", toolName, err)
+		return newError(CodeInternal, "could not get confirmation from the user")
+	}
+
+	if result.Action != mcp.ElicitationResponseActionAccept {
+		return newError(CodeConfirmationRequired, fmt.Sprintf("%s was not confirmed by the user (%s)", toolName, result.Action))
+	}
+	if content, ok := result.Content.(map[string]any); !ok || content["confirm"] != true {
+		return newError(CodeConfirmationRequired, fmt.Sprintf("%s was not confirmed by the user", toolName))
+	}
+
+	return nil
+}
+
+// supportsElicitation reports whether the client declared the elicitation
+// capability when the session was initialized
+func supportsElicitation(session server.ClientSession) bool {
+	if _, ok := session.(server.SessionWithElicitation); !ok {
+		return false
+	}
+	withInfo, ok := session.(server.SessionWithClientInfo)
+	return ok && withInfo.GetClientCapabilities().Elicitation != nil
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// elicitingSession is a client session that supports elicitation and answers
+// every request with the given result
+type elicitingSession struct {
+	testSession
+	capabilities mcp.ClientCapabilities
+	answer       mcp.ElicitationResult
+	messages     []string
+}
+
+var _ server.SessionWithElicitation = (*elicitingSession)(nil)
+
+func (s *elicitingSession) GetClientInfo() mcp.Implementation              { return mcp.Implementation{} }
+func (s *elicitingSession) SetClientInfo(mcp.Implementation)               {}
+func (s *elicitingSession) GetClientCapabilities() mcp.ClientCapabilities  { return s.capabilities }
+func (s *elicitingSession) SetClientCapabilities(c mcp.ClientCapabilities) { s.capabilities = c }
+func (s *elicitingSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	s.messages = append(s.messages, request.Params.Message)
+	return &s.answer, nil
+}
+
+// elicitingContext opens a session for the token that answers elicitation
+// requests with the action and content; without an action the client does
+// not declare the elicitation capability
+func (s *testServer) elicitingContext(t *testing.T, token string, action mcp.ElicitationResponseAction, content any) (context.Context, *elicitingSession) {
+	t.Helper()
+	session := &elicitingSession{
+		testSession: testSession{id: uuid.New().String(), notifications: make(chan mcp.JSONRPCNotification, 100)},
+		answer:      mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: action, Content: content}},
+	}
+	if action != "" {
+		session.capabilities.Elicitation = &mcp.ElicitationCapability{}
+	}
+	if err := s.mcp.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("RegisterSession: %v", err)
+	}
+	t.Cleanup(func() { s.mcp.UnregisterSession(context.Background(), session.id) })
+	return auth.WithToken(s.mcp.WithContext(context.Background(), session), token), session
+}
+
+func TestCancelTaskConfirmation(t *testing.T) {
+	s := newTestServer(t, RegisterCancelTaskTool)
+	alice, token := s.addUser(t, "alice")
+	bob, _ := s.addUser(t, "bob")
+
+	tests := []struct {
+		name      string
+		action    mcp.ElicitationResponseAction
+		content   any
+		confirmed bool
+	}{
+		{"declined", mcp.ElicitationResponseActionDecline, nil, false},
+		{"cancelled", mcp.ElicitationResponseActionCancel, nil, false},
+		{"accepted unchecked", mcp.ElicitationResponseActionAccept, map[string]any{"confirm": false}, false},
+		{"accepted", mcp.ElicitationResponseActionAccept, map[string]any{"confirm": true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID := s.addTask(t, alice.ID, bob.ID, models.StatusPending)
+			ctx, session := s.elicitingContext(t, token, tt.action, tt.content)
+
+			result := s.callToolContext(t, ctx, "cancel_task", map[string]any{"id": taskID, "reason": "obsolete"})
+			if len(session.messages) != 1 || !strings.Contains(session.messages[0], "bob") || !strings.Contains(session.messages[0], "obsolete") {
+				t.Errorf("asked %q, want one question naming the assignee and reason", session.messages)
+			}
+
+			task, err := s.st.GetTask(context.Background(), s.orgID, taskID)
+			if err != nil {
+				t.Fatalf("GetTask: %v", err)
+			}
+			if tt.confirmed {
+				if result.IsError || task.Status != string(models.StatusCancelled) {
+					t.Errorf("confirmed: error = %v, status = %s, want cancelled", result.Content, task.Status)
+				}
+				return
+			}
+			if ResultErrorCode(result) != CodeConfirmationRequired || task.Status != string(models.StatusPending) {
+				t.Errorf("not confirmed: code = %s, status = %s, want confirmation_required and pending", ResultErrorCode(result), task.Status)
+			}
+		})
+	}
+}
+
+func TestDeleteUserConfirmation(t *testing.T) {
+	s := newTestServer(t, RegisterDeleteUserTool)
+	_, token := s.addAdmin(t, "admin")
+
+	for _, tt := range []struct {
+		action    mcp.ElicitationResponseAction
+		content   any
+		confirmed bool
+	}{
+		{mcp.ElicitationResponseActionDecline, nil, false},
+		{mcp.ElicitationResponseActionAccept, map[string]any{"confirm": true}, true},
+	} {
+		user, _ := s.addUser(t, "leaving-"+string(tt.action))
+		ctx, session := s.elicitingContext(t, token, tt.action, tt.content)
+
+		result := s.callToolContext(t, ctx, "delete_user", map[string]any{"user_name": user.Name})
+		if len(session.messages) != 1 || !strings.Contains(session.messages[0], user.Name) {
+			t.Errorf("%s: asked %q, want one question naming the user", tt.action, session.messages)
+		}
+
+		_, err := s.st.GetUser(context.Background(), user.ID)
+		if tt.confirmed && (result.IsError || err != store.ErrNotFound) {
+			t.Errorf("%s: error = %v, lookup = %v, want the user deleted", tt.action, result.Content, err)
+		}
+		if !tt.confirmed && (ResultErrorCode(result) != CodeConfirmationRequired || err != nil) {
+			t.Errorf("%s: code = %s, lookup = %v, want confirmation_required and the user kept", tt.action, ResultErrorCode(result), err)
+		}
+	}
+}
+
+func TestConfirmationWithoutElicitation(t *testing.T) {
+	s := newTestServer(t, RegisterCancelTaskTool)
+	alice, token := s.addUser(t, "alice")
+
+	// Clients without elicitation run the tool unless it requires confirmation
+	taskID := s.addTask(t, alice.ID, alice.ID, models.StatusPending)
+	ctx, _ := s.elicitingContext(t, token, "", nil)
+	if result := s.callToolContext(t, ctx, "cancel_task", map[string]any{"id": taskID, "reason": "obsolete"}); result.IsError {
+		t.Errorf("cancel_task without elicitation failed: %v", result.Content)
+	}
+
+	if err := RequireConfirmation([]string{"cancel_task"}); err != nil {
+		t.Fatalf("RequireConfirmation: %v", err)
+	}
+	t.Cleanup(func() { delete(confirmationRequired, "cancel_task") })
+
+	taskID = s.addTask(t, alice.ID, alice.ID, models.StatusPending)
+	result := s.callToolContext(t, ctx, "cancel_task", map[string]any{"id": taskID, "reason": "obsolete"})
+	if ResultErrorCode(result) != CodeConfirmationRequired {
+		t.Errorf("required confirmation without elicitation: code = %s, want confirmation_required", ResultErrorCode(result))
+	}
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterCreateAPIKeyTool registers the create_api_key tool
+func RegisterCreateAPIKeyTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("create_api_key",
+		mcp.WithDescription("Create a long-lived API key for the current user or specified user (admins only). The key is shown only once."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name describing what the key is used for (e.g. 'nightly-cron')"),
+		),
+		mcp.WithString("user_name",
+			mcp.Description("Username to create the key for. If not provided, uses current user. Only admins can specify other users."),
+		),
+		mcp.WithOutputSchema[models.CreatedAPIKeyResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		name, err := request.RequireString("name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "name is required"), nil
+		}
+
+		// Determine key owner
+		var owner *models.UserWithRoles
+		userName := request.GetString("user_name", "")
+		if userName != "" {
+			if err := auth.Authorize(claims, auth.PermUsersManage); err != nil {
+				return errorResult(err, CodePermissionDenied), nil
+			}
+
+			owner, err = st.GetUserByName(ctx, claims.OrgID, userName)
+		} else {
+			owner, err = st.GetUser(ctx, claims.UserID)
+		}
+		if err != nil {
+			if err == store.ErrNotFound && userName != "" {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName)), nil
+			}
+			log.Printf("Error finding API key owner: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		// Generate the key; only its hash is stored
+		keyID := generateUUID()
+		apiKey, keyHash, err := auth.GenerateAPIKey(keyID)
+		if err != nil {
+			log.Printf("Error generating API key: %v", err)
+			return toolError(CodeInternal, "failed to generate API key"), nil
+		}
+
+		key := models.APIKey{
+			ID:      keyID,
+			UserID:  owner.ID,
+			Name:    name,
+			KeyHash: keyHash,
+		}
+		err = st.CreateAPIKey(ctx, &key)
+		if err != nil {
+			log.Printf("Error creating API key: %v", err)
+			return toolError(CodeInternal, "failed to create API key"), nil
+		}
+
+		result := models.CreatedAPIKeyResponse{
+			ID:        keyID,
+			Name:      name,
+			APIKey:    apiKey,
+			UserID:    owner.ID,
+			UserName:  owner.Name,
+			CreatedAt: key.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			Message:   "Store this key securely, it cannot be retrieved again. Send it in the X-API-Key header.",
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("API key created: %s for %s (ID: %s)", name, owner.Name, keyID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermAPIKeysOwn, handler))
+	log.Println("create_api_key tool registered")
+	return nil
+}
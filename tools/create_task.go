@@ -6,11 +6,10 @@ import (
 	"fmt"
 	"log"
 	"regexp"
-	"strings"
 
 	"github.com/dushes/simple-task-mcp/auth"
-	"github.com/dushes/simple-task-mcp/database"
 	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
 	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -30,121 +29,133 @@ func generateUUID() string {
 }
 
 // RegisterCreateTaskTool registers the create_task tool
-func RegisterCreateTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager) error {
+func RegisterCreateTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	createTaskTool := mcp.NewTool("create_task",
-		mcp.WithDescription("Create a new task and assign it to a user"),
+		mcp.WithDescription("Create a new task and assign it to a user or a team"),
 		mcp.WithString("description",
 			mcp.Required(),
 			mcp.Description("Task description"),
 		),
 		mcp.WithString("assigned_to",
-			mcp.Required(),
-			mcp.Description("Username to assign the task to"),
+			mcp.Description("Username to assign the task to. Either assigned_to or assigned_team is required."),
+		),
+		mcp.WithString("assigned_team",
+			mcp.Description("Team name to assign the task to. The task goes to the active member with the fewest open tasks."),
 		),
+		mcp.WithOutputSchema[models.TaskWithUserNames](),
 	)
 
-	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract JWT token from Authorization header
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			return mcp.NewToolResultError("Authorization header is required"), nil
-		}
-
-		// Remove "Bearer " prefix if present
-		authToken := authHeader
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			authToken = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-
-		// Validate JWT token
-		claims, err := jwtManager.ValidateToken(authToken)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid token: %v", err)), nil
-		}
-
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
 		// Extract parameters
 		description, err := request.RequireString("description")
 		if err != nil {
-			return mcp.NewToolResultError("description is required"), nil
+			return toolError(CodeInvalidInput, "description is required"), nil
 		}
 
-		assignedToUsername, err := request.RequireString("assigned_to")
-		if err != nil {
-			return mcp.NewToolResultError("assigned_to is required"), nil
+		assignedToUsername := request.GetString("assigned_to", "")
+		assignedTeamName := request.GetString("assigned_team", "")
+		if (assignedToUsername == "") == (assignedTeamName == "") {
+			return toolError(CodeInvalidInput, "exactly one of assigned_to or assigned_team is required"), nil
 		}
 
 		// Validate UUID format for creator
 		if !isValidUUID(claims.UserID) {
-			return mcp.NewToolResultError("invalid user ID in token"), nil
+			return toolError(CodeUnauthenticated, "invalid user ID in token"), nil
 		}
 
-		// Get assigned_to user ID and validate existence
 		var assignedToID string
-		err = database.DB.QueryRow("SELECT id FROM users WHERE name = $1", assignedToUsername).Scan(&assignedToID)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				return mcp.NewToolResultError(fmt.Sprintf("user '%s' does not exist", assignedToUsername)), nil
+		var team *models.Team
+		if assignedTeamName != "" {
+			// Resolve the team and pick the member to work on the task
+			team, err = st.GetTeamByName(ctx, claims.OrgID, assignedTeamName)
+			if err != nil {
+				if err == store.ErrNotFound {
+					return toolError(CodeNotFound, fmt.Sprintf("team '%s' does not exist", assignedTeamName)), nil
+				}
+				log.Printf("Error finding team by name: %v", err)
+				return toolError(CodeInternal, "database error"), nil
 			}
-			log.Printf("Error finding user by name: %v", err)
-			return mcp.NewToolResultError("database error"), nil
+
+			assignee, err := st.PickTeamAssignee(ctx, team.ID)
+			if err == store.ErrTeamHasNoMembers {
+				return toolError(CodeConflict, fmt.Sprintf("cannot assign to team '%s': %v", team.Name, err)), nil
+			}
+			if err != nil {
+				log.Printf("Error picking team assignee: %v", err)
+				return toolError(CodeInternal, "database error"), nil
+			}
+			assignedToID, assignedToUsername = assignee.ID, assignee.Name
+		} else {
+			// Get assigned_to user ID and validate existence
+			assignee, err := st.GetUserByName(ctx, claims.OrgID, assignedToUsername)
+			if err != nil {
+				if err == store.ErrNotFound {
+					return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", assignedToUsername)), nil
+				}
+				log.Printf("Error finding user by name: %v", err)
+				return toolError(CodeInternal, "database error"), nil
+			}
+			if assignee.IsDisabled {
+				return toolError(CodeInvalidInput, fmt.Sprintf("user '%s' is disabled", assignedToUsername)), nil
+			}
+			assignedToID = assignee.ID
 		}
 
 		// Get creator username
-		var creatorName string
-		err = database.DB.QueryRow("SELECT name FROM users WHERE id = $1", claims.UserID).Scan(&creatorName)
+		creator, err := st.GetUser(ctx, claims.UserID)
 		if err != nil {
 			log.Printf("Error getting creator name: %v", err)
-			return mcp.NewToolResultError("database error"), nil
+			return toolError(CodeInternal, "database error"), nil
 		}
 
 		// Create the task
 		taskID := generateUUID()
 		task := models.Task{
 			ID:          taskID,
+			OrgID:       claims.OrgID,
 			Description: description,
 			Status:      models.StatusPending,
 			CreatedBy:   claims.UserID,
 			AssignedTo:  assignedToID,
 			IsArchived:  false,
 		}
+		if team != nil {
+			task.AssignedTeamID = sql.NullString{String: team.ID, Valid: true}
+		}
 
 		// Insert into database
-		query := `
-			INSERT INTO tasks (id, description, status, created_by, assigned_to, is_archived)
-			VALUES ($1, $2, $3, $4, $5, $6)
-			RETURNING created_at, updated_at`
-
-		err = database.DB.QueryRow(query,
-			task.ID,
-			task.Description,
-			task.Status,
-			task.CreatedBy,
-			task.AssignedTo,
-			task.IsArchived,
-		).Scan(&task.CreatedAt, &task.UpdatedAt)
-
+		err = st.CreateTask(ctx, &task)
 		if err != nil {
 			log.Printf("Error creating task: %v", err)
-			return mcp.NewToolResultError("failed to create task"), nil
+			return toolError(CodeInternal, "failed to create task"), nil
 		}
 
 		// Return the created task with usernames
-		result := map[string]interface{}{
-			"id":               task.ID,
-			"description":      task.Description,
-			"status":           string(task.Status),
-			"created_by":       task.CreatedBy,
-			"created_by_name":  creatorName,
-			"assigned_to":      task.AssignedTo,
-			"assigned_to_name": assignedToUsername,
-			"created_at":       task.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			"updated_at":       task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		result := models.TaskWithUsers{
+			ID:           task.ID,
+			Description:  task.Description,
+			Status:       string(task.Status),
+			CreatedBy:    creator.Name,
+			CreatedByID:  task.CreatedBy,
+			AssignedTo:   assignedToUsername,
+			AssignedToID: task.AssignedTo,
+			IsArchived:   task.IsArchived,
+			CreatedAt:    task.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:    task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 		}
 
-		return mcp.NewToolResultStructured(result, fmt.Sprintf("Task created: %s (ID: %s)", task.Description, task.ID)), nil
+		if team != nil {
+			result.AssignedTeamID = &team.ID
+			result.AssignedTeam = &team.Name
+		}
+
+		// Push the new task to subscribers of the assignee's inbox
+		notifyTaskChanged(ctx, st, taskChange{OrgID: claims.OrgID, TaskID: task.ID, AssigneeName: assignedToUsername})
+
+		return mcp.NewToolResultStructured(result.WithUserNames(), fmt.Sprintf("Task created: %s (ID: %s)", task.Description, task.ID)), nil
 	}
 
-	s.AddTool(createTaskTool, handler)
+	s.AddTool(createTaskTool, requirePermission(auth.PermTasksCreate, handler))
 	log.Println("create_task tool registered")
 	return nil
 }
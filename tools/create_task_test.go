@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+func TestTaskResponsesKeepUserNames(t *testing.T) {
+	s := newTestServer(t, RegisterCreateTaskTool, RegisterCancelTaskTool)
+	alice, aliceToken := s.addUser(t, "alice")
+	bob, _ := s.addUser(t, "bob")
+
+	// The deprecated *_name keys repeat the names until clients move off them
+	expect := func(tool string, task models.TaskWithUserNames) {
+		t.Helper()
+		if task.CreatedBy != "alice" || task.CreatedByID != alice.ID || task.CreatedByName != "alice" {
+			t.Errorf("%s: created by %q (ID %q, name %q)", tool, task.CreatedBy, task.CreatedByID, task.CreatedByName)
+		}
+		if task.AssignedTo != "bob" || task.AssignedToID != bob.ID || task.AssignedToName != "bob" {
+			t.Errorf("%s: assigned to %q (ID %q, name %q)", tool, task.AssignedTo, task.AssignedToID, task.AssignedToName)
+		}
+	}
+
+	var created models.TaskWithUserNames
+	decodeResult(t, s.callTool(t, aliceToken, "create_task", map[string]any{"description": "deploy", "assigned_to": "bob"}), &created)
+	expect("create_task", created)
+
+	var cancelled models.TaskWithUserNames
+	decodeResult(t, s.callTool(t, aliceToken, "cancel_task", map[string]any{"id": created.ID, "reason": "not needed"}), &cancelled)
+	expect("cancel_task", cancelled)
+}
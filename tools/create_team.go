@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterCreateTeamTool registers the create_team tool
+func RegisterCreateTeamTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("create_team",
+		mcp.WithDescription("Create a new team (admin only)"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the team"),
+		),
+		mcp.WithString("description",
+			mcp.Description("Optional description of the team"),
+		),
+		mcp.WithOutputSchema[models.Team](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		name, err := request.RequireString("name")
+		if err != nil || strings.TrimSpace(name) == "" {
+			return toolError(CodeInvalidInput, "name is required"), nil
+		}
+		description := request.GetString("description", "")
+
+		team := models.Team{OrgID: claims.OrgID, Name: strings.TrimSpace(name), Description: description}
+		err = st.CreateTeam(ctx, &team)
+		if err != nil {
+			if err == store.ErrDuplicate {
+				return toolError(CodeConflict, fmt.Sprintf("team with name '%s' already exists", team.Name)), nil
+			}
+			log.Printf("Error creating team: %v", err)
+			return toolError(CodeInternal, "failed to create team"), nil
+		}
+
+		return mcp.NewToolResultStructured(team, fmt.Sprintf("Team created: %s (ID: %s)", team.Name, team.ID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("create_team tool registered")
+	return nil
+}
@@ -2,19 +2,19 @@ package tools
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"log"
 	"strings"
 
 	"github.com/dushes/simple-task-mcp/auth"
-	"github.com/dushes/simple-task-mcp/database"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // RegisterCreateUserTool registers the create_user tool
-func RegisterCreateUserTool(s *server.MCPServer, jwtManager *auth.JWTManager) error {
+func RegisterCreateUserTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	tool := mcp.NewTool("create_user",
 		mcp.WithDescription("Create a new user in the system (admin only)"),
 		mcp.WithString("name",
@@ -27,36 +27,18 @@ func RegisterCreateUserTool(s *server.MCPServer, jwtManager *auth.JWTManager) er
 		mcp.WithBoolean("is_admin",
 			mcp.Description("Whether the user should have admin privileges (default: false)"),
 		),
+		mcp.WithArray("roles",
+			mcp.Description(fmt.Sprintf("Roles to assign. Available roles: %s. If not provided, the user gets the default '%s' role.", strings.Join(auth.ValidRoles(), ", "), auth.DefaultRole)),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithOutputSchema[models.UserTokenResponse](),
 	)
 
-	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract JWT token from Authorization header
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			return mcp.NewToolResultError("Authorization header is required"), nil
-		}
-
-		// Remove "Bearer " prefix if present
-		authToken := authHeader
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			authToken = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-
-		// Validate JWT token
-		claims, err := jwtManager.ValidateToken(authToken)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid token: %v", err)), nil
-		}
-
-		// Check if user is admin
-		if !claims.IsAdmin {
-			return mcp.NewToolResultError("only admins can create users"), nil
-		}
-
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
 		// Extract parameters
 		name, err := request.RequireString("name")
 		if err != nil {
-			return mcp.NewToolResultError("name is required"), nil
+			return toolError(CodeInvalidInput, "name is required"), nil
 		}
 
 		// Default is_admin to false if not provided
@@ -65,91 +47,65 @@ func RegisterCreateUserTool(s *server.MCPServer, jwtManager *auth.JWTManager) er
 		// Get optional description
 		description := request.GetString("description", "")
 
-		// Create user in database
-		var userID string
-		var query string
-
-		if description != "" {
-			query = `
-				INSERT INTO users (name, description, is_admin)
-				VALUES ($1, $2, $3)
-				RETURNING id
-			`
-			err = database.DB.QueryRow(query, name, description, isAdmin).Scan(&userID)
-		} else {
-			query = `
-				INSERT INTO users (name, is_admin)
-				VALUES ($1, $2)
-				RETURNING id
-			`
-			err = database.DB.QueryRow(query, name, isAdmin).Scan(&userID)
+		// Get optional roles; the admin role is the same as is_admin
+		roleAdmin, roles, err := parseRoles(request.GetStringSlice("roles", nil))
+		if err != nil {
+			return errorResult(err, CodeInvalidInput), nil
+		}
+		isAdmin = isAdmin || roleAdmin
+
+		// Create user in database together with its roles
+		newUser := models.User{
+			OrgID:       claims.OrgID,
+			Name:        name,
+			Description: description,
+			IsAdmin:     isAdmin,
 		}
+		err = st.CreateUser(ctx, &newUser, roles)
 		if err != nil {
-			if strings.Contains(err.Error(), "users_name_unique") || strings.Contains(err.Error(), "duplicate key value") {
-				return mcp.NewToolResultError(fmt.Sprintf("user with name '%s' already exists", name)), nil
+			if err == store.ErrDuplicate {
+				return toolError(CodeConflict, fmt.Sprintf("user with name '%s' already exists", name)), nil
 			}
-			return mcp.NewToolResultError(fmt.Sprintf("failed to create user: %v", err)), nil
+			return toolError(CodeInternal, fmt.Sprintf("failed to create user: %v", err)), nil
 		}
+		userID := newUser.ID
 
 		// Generate token for the new user
-		newUserToken, err := jwtManager.GenerateToken(userID, isAdmin)
+		newUserToken, err := jwtManager.GenerateToken(userID, claims.OrgID, isAdmin, newUser.TokenGeneration)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("failed to generate token for new user: %v", err)), nil
+			return toolError(CodeInternal, fmt.Sprintf("failed to generate token for new user: %v", err)), nil
+		}
+
+		user, err := getOrgUser(ctx, st, claims.OrgID, userID)
+		if err != nil {
+			log.Printf("Error loading created user: %v", err)
+			return toolError(CodeInternal, "database error"), nil
 		}
 
 		// Return success with user details
-		result := map[string]interface{}{
-			"token":   newUserToken,
-			"success": true,
-			"user": map[string]interface{}{
-				"id":       userID,
-				"name":     name,
-				"is_admin": isAdmin,
-				"token":    newUserToken,
-			},
-			"message": fmt.Sprintf("User '%s' created successfully", name),
+		result := models.UserTokenResponse{
+			Success: true,
+			Token:   newUserToken,
+			User:    *user,
+			Message: fmt.Sprintf("User '%s' created successfully", name),
 		}
 
 		return mcp.NewToolResultStructured(result, fmt.Sprintf("User created: %s (ID: %s)", name, userID)), nil
 	}
 
-	s.AddTool(tool, handler)
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
 	log.Println("create_user tool registered")
 	return nil
 }
 
-// GetUserByID retrieves user information by ID
-func GetUserByID(userID string) (map[string]interface{}, error) {
-	var name string
-	var isAdmin bool
-	var createdAt, updatedAt sql.NullTime
-
-	query := `
-		SELECT name, is_admin, created_at, updated_at
-		FROM users
-		WHERE id = $1
-	`
-
-	err := database.DB.QueryRow(query, userID).Scan(&name, &isAdmin, &createdAt, &updatedAt)
+// getOrgUser retrieves a user with its effective roles by ID within an organization
+func getOrgUser(ctx context.Context, st store.Store, orgID string, userID string) (*models.UserWithRoles, error) {
+	user, err := st.GetUser(ctx, userID)
+	if err == store.ErrNotFound || (err == nil && user.OrgID != orgID) {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
 		return nil, err
 	}
-
-	user := map[string]interface{}{
-		"id":       userID,
-		"name":     name,
-		"is_admin": isAdmin,
-	}
-
-	if createdAt.Valid {
-		user["created_at"] = createdAt.Time
-	}
-	if updatedAt.Valid {
-		user["updated_at"] = updatedAt.Time
-	}
-
 	return user, nil
 }
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterDeleteUserTool registers the delete_user tool
+func RegisterDeleteUserTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("delete_user",
+		mcp.WithDescription("Delete a user (admin only). Tasks referencing the user are transferred to the reassignment target; the user's comments and progress reports are kept and shown as by a deleted user."),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the user to delete"),
+		),
+		mcp.WithString("reassign_to",
+			mcp.Description("Username that receives the deleted user's tasks. Required if the user has any tasks."),
+		),
+		mcp.WithOutputSchema[models.DeletedUserResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		userName, err := request.RequireString("user_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+		reassignTo := request.GetString("reassign_to", "")
+
+		// Find the user
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName)), nil
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		userID := user.ID
+		if userID == claims.UserID {
+			return toolError(CodeConflict, "cannot delete yourself"), nil
+		}
+
+		// Check whether any tasks reference the user
+		referenceCount, err := st.CountUserReferences(ctx, userID)
+		if err != nil {
+			log.Printf("Error counting user references: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		// Resolve the reassignment target
+		var targetID string
+		if referenceCount > 0 && reassignTo == "" {
+			return toolError(CodeInvalidInput, fmt.Sprintf("user '%s' has tasks, reassign_to is required", userName)), nil
+		}
+		if reassignTo != "" {
+			target, err := st.GetUserByName(ctx, claims.OrgID, reassignTo)
+			if err != nil {
+				if err == store.ErrNotFound {
+					return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", reassignTo)), nil
+				}
+				log.Printf("Error finding user by name: %v", err)
+				return toolError(CodeInternal, "database error"), nil
+			}
+			targetID = target.ID
+			if targetID == userID {
+				return toolError(CodeInvalidInput, "reassign_to must be a different user"), nil
+			}
+			if target.IsDisabled {
+				return toolError(CodeInvalidInput, fmt.Sprintf("user '%s' is disabled", reassignTo)), nil
+			}
+		}
+
+		// Ask the user to confirm, showing what happens to the user's tasks
+		message := fmt.Sprintf("Delete the user '%s'? This cannot be undone.", userName)
+		if referenceCount > 0 {
+			message += fmt.Sprintf("\n\nTheir %d tasks will be transferred to '%s'.", referenceCount, reassignTo)
+		}
+		if err := confirm(ctx, "delete_user", message); err != nil {
+			return errorResult(err, CodeInternal), nil
+		}
+
+		// Remember the open tasks that go to the target's queue, so that their
+		// subscribers can be notified
+		var reassigned []models.TaskWithUsers
+		if targetID != "" {
+			reassigned, err = st.ListTasks(ctx, store.TaskFilter{
+				OrgID:           claims.OrgID,
+				AssignedTo:      userID,
+				Statuses:        []models.TaskStatus{models.StatusPending, models.StatusInProgress, models.StatusWaitingForUser},
+				ExcludeArchived: true,
+			})
+			if err != nil {
+				log.Printf("Error listing open tasks of user: %v", err)
+				return toolError(CodeInternal, "database error"), nil
+			}
+		}
+
+		// Open tasks go to the target's queue and all other tasks are transferred
+		openTasksReassigned, err := st.DeleteUser(ctx, userID, targetID)
+		if err != nil {
+			log.Printf("Error deleting user: %v", err)
+			return toolError(CodeInternal, "failed to delete user"), nil
+		}
+
+		for _, task := range reassigned {
+			notifyTaskChanged(ctx, st, taskChange{OrgID: claims.OrgID, TaskID: task.ID, AssigneeName: reassignTo})
+		}
+
+		result := models.DeletedUserResponse{
+			ID:                  userID,
+			Name:                userName,
+			Deleted:             true,
+			OpenTasksReassigned: openTasksReassigned,
+			ReassignedTo:        reassignTo,
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("User deleted: %s (ID: %s)", userName, userID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("delete_user tool registered")
+	return nil
+}
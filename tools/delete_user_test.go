@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+func TestDeleteUserNotifiesReassignedTasks(t *testing.T) {
+	s := newTestServer(t, RegisterDeleteUserTool)
+	_, adminToken := s.addAdmin(t, "admin")
+	creator, creatorToken := s.addUser(t, "creator")
+	leaving, _ := s.addUser(t, "leaving")
+	_, heirToken := s.addUser(t, "heir")
+
+	pending := s.addTask(t, creator.ID, leaving.ID, models.StatusPending)
+	waiting := s.addTask(t, creator.ID, leaving.ID, models.StatusWaitingForUser)
+	completed := s.addTask(t, creator.ID, leaving.ID, models.StatusCompleted)
+
+	var taskSessions []*testSession
+	for _, taskID := range []string{pending, waiting, completed} {
+		taskSessions = append(taskSessions, s.subscribe(t, creatorToken, "task://"+taskID))
+	}
+	inbox := s.subscribe(t, heirToken, "user://heir/inbox")
+
+	result := s.callTool(t, adminToken, "delete_user", map[string]any{"user_name": "leaving", "reassign_to": "heir"})
+	var response models.DeletedUserResponse
+	decodeResult(t, result, &response)
+	if response.OpenTasksReassigned != 2 {
+		t.Fatalf("%d open tasks reassigned, want 2", response.OpenTasksReassigned)
+	}
+
+	for i, taskID := range []string{pending, waiting} {
+		if uris := taskSessions[i].updatedURIs(); !slices.Equal(uris, []string{"task://" + taskID}) {
+			t.Errorf("task %s: notified about %v", taskID, uris)
+		}
+	}
+	if uris := taskSessions[2].updatedURIs(); len(uris) != 0 {
+		t.Errorf("completed task: notified about %v, want nothing", uris)
+	}
+	if uris := inbox.updatedURIs(); len(uris) != 2 {
+		t.Errorf("inbox of heir: notified about %v, want both reassigned tasks", uris)
+	}
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterDisableUserTool registers the disable_user tool
+func RegisterDisableUserTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("disable_user",
+		mcp.WithDescription("Disable or re-enable a user (admin only). Tokens and API keys of disabled users are rejected."),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the user to disable"),
+		),
+		mcp.WithBoolean("disabled",
+			mcp.Description("Set to false to re-enable the user (default: true)"),
+		),
+		mcp.WithOutputSchema[models.UserWithRoles](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		userName, err := request.RequireString("user_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+		disabled := request.GetBool("disabled", true)
+
+		// Find the user
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName)), nil
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		userID := user.ID
+		if userID == claims.UserID {
+			return toolError(CodeConflict, "cannot disable yourself"), nil
+		}
+
+		if user.IsDisabled == disabled {
+			if disabled {
+				return toolError(CodeConflict, fmt.Sprintf("user '%s' is already disabled", userName)), nil
+			}
+			return toolError(CodeConflict, fmt.Sprintf("user '%s' is not disabled", userName)), nil
+		}
+
+		if err := st.SetUserDisabled(ctx, userID, disabled); err != nil {
+			log.Printf("Error updating user disabled flag: %v", err)
+			return toolError(CodeInternal, "failed to update user"), nil
+		}
+
+		action := "disabled"
+		if !disabled {
+			action = "enabled"
+		}
+
+		result, err := getOrgUser(ctx, st, claims.OrgID, userID)
+		if err != nil {
+			log.Printf("Error loading updated user: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("User %s: %s (ID: %s)", action, userName, userID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("disable_user tool registered")
+	return nil
+}
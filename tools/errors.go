@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ErrorCode classifies the errors of tools, resources and prompts for callers
+// that need more than the message, such as the REST API
+type ErrorCode string
+
+const (
+	CodeInvalidInput         ErrorCode = "invalid_input"
+	CodeUnauthenticated      ErrorCode = "unauthenticated"
+	CodePermissionDenied     ErrorCode = "permission_denied"
+	CodeNotFound             ErrorCode = "not_found"
+	CodeConflict             ErrorCode = "conflict"
+	CodeConfirmationRequired ErrorCode = "confirmation_required"
+	CodeInternal             ErrorCode = "internal"
+)
+
+// errorCodeMetaKey is the _meta field of a tool error result that holds the
+// error code
+const errorCodeMetaKey = "errorCode"
+
+// Error is an error of a tool, resource or prompt with its code
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError creates an error with a code
+func newError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// errDatabase is returned when the store fails; the cause is only logged
+var errDatabase = newError(CodeInternal, "database error")
+
+// ErrorCodeOf returns the code of an error returned by this package. Errors
+// without a code are internal errors.
+func ErrorCodeOf(err error) ErrorCode {
+	return codeOf(err, CodeInternal)
+}
+
+// codeOf returns the code of an error, or fallback if it has none
+func codeOf(err error, fallback ErrorCode) ErrorCode {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	if errors.Is(err, auth.ErrPermissionDenied) {
+		return CodePermissionDenied
+	}
+	return fallback
+}
+
+// toolError returns a tool error result with the code in its _meta, so that
+// clients can tell errors apart without parsing the message
+func toolError(code ErrorCode, message string) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(message)
+	result.Meta = mcp.NewMetaFromMap(map[string]any{errorCodeMetaKey: string(code)})
+	return result
+}
+
+// errorResult returns a tool error result for an error, with its code or
+// fallback if it has none
+func errorResult(err error, fallback ErrorCode) *mcp.CallToolResult {
+	return toolError(codeOf(err, fallback), err.Error())
+}
+
+// ResultErrorCode returns the code of a tool error result. Results without a
+// code, which this package does not produce, are internal errors.
+func ResultErrorCode(result *mcp.CallToolResult) ErrorCode {
+	if result.Meta != nil {
+		if code, ok := result.Meta.AdditionalFields[errorCodeMetaKey].(string); ok {
+			return ErrorCode(code)
+		}
+	}
+	return CodeInternal
+}
+
+// errorRecorder holds the error of the last resource read or prompt of an
+// in-process caller
+type errorRecorder struct {
+	err error
+}
+
+// errorRecorderKey is the context key of the errorRecorder
+type errorRecorderKey struct{}
+
+// WithErrorRecorder returns a context that records the error of a resource
+// read or prompt, and a function returning it. JSON-RPC errors only carry the
+// message, so in-process callers use it to get the code with ErrorCodeOf.
+func WithErrorRecorder(ctx context.Context) (context.Context, func() error) {
+	recorder := &errorRecorder{}
+	return context.WithValue(ctx, errorRecorderKey{}, recorder), func() error { return recorder.err }
+}
+
+// recordError records the error of a resource read or prompt for in-process
+// callers and returns it
+func recordError(ctx context.Context, err error) error {
+	if recorder, ok := ctx.Value(errorRecorderKey{}).(*errorRecorder); ok {
+		recorder.err = err
+	}
+	return err
+}
@@ -2,69 +2,43 @@ package tools
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/dushes/simple-task-mcp/auth"
-	"github.com/dushes/simple-task-mcp/database"
 	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// maxWaitSeconds caps how long get_next_task blocks waiting for a task
+const maxWaitSeconds = 300
+
 // GetNextTaskInput represents the input for get_next_task tool
 type GetNextTaskInput struct {
-	Statuses []string `json:"statuses"`
-}
-
-// GetNextTaskOutput represents the output for get_next_task tool
-type GetNextTaskOutput struct {
-	ID           string                       `json:"id"`
-	Description  string                       `json:"description"`
-	Status       string                       `json:"status"`
-	CreatedBy    string                       `json:"created_by"`
-	CreatedByID  string                       `json:"created_by_id"`
-	AssignedTo   string                       `json:"assigned_to"`
-	AssignedToID string                       `json:"assigned_to_id"`
-	Result       *string                      `json:"result,omitempty"`
-	Comments     []models.TaskCommentWithUser `json:"comments,omitempty"`
-	CreatedAt    string                       `json:"created_at"`
-	UpdatedAt    string                       `json:"updated_at"`
-	CompletedAt  *string                      `json:"completed_at,omitempty"`
+	Statuses    []string `json:"statuses"`
+	WaitSeconds *int     `json:"wait_seconds,omitempty"`
 }
 
 // RegisterGetNextTaskTool registers the get_next_task tool
-func RegisterGetNextTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager) error {
+func RegisterGetNextTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	getNextTaskTool := mcp.NewTool("get_next_task",
-		mcp.WithDescription("Get one task where the current user is assignee, filtered by status"),
+		mcp.WithDescription("Get one task where the current user is assignee, filtered by status. Can block until a task appears instead of polling."),
 		mcp.WithArray("statuses",
 			mcp.Description("Array of statuses to filter by. Available statuses: pending, in_progress, waiting_for_user, completed, cancelled. If not provided, defaults to [\"pending\"]"),
 			mcp.Items(map[string]any{"type": "string"}),
 		),
+		mcp.WithNumber("wait_seconds",
+			mcp.Description(fmt.Sprintf("If no matching task exists, block until one appears or this many seconds pass (default: 0 - return immediately, max: %d)", maxWaitSeconds)),
+		),
+		mcp.WithOutputSchema[models.NextTaskResponse](),
 	)
 
-	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract JWT token from Authorization header
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			return mcp.NewToolResultError("Authorization header is required"), nil
-		}
-
-		// Remove "Bearer " prefix if present
-		token := authHeader
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			token = authHeader[7:]
-		}
-
-		// Validate JWT token
-		claims, err := jwtManager.ValidateToken(token)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid token: %v", err)), nil
-		}
-
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
 		userID := claims.UserID
 
 		// Parse input
@@ -72,11 +46,11 @@ func RegisterGetNextTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager) e
 		inputBytes, err := json.Marshal(request.Params.Arguments)
 		if err != nil {
 			log.Printf("Error marshaling args: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse input: %v", err)), nil
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
 		}
 		if err := json.Unmarshal(inputBytes, &input); err != nil {
 			log.Printf("Error unmarshaling input: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse input: %v", err)), nil
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
 		}
 
 		// Use default value if statuses not provided
@@ -99,135 +73,95 @@ func RegisterGetNextTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager) e
 		for _, status := range input.Statuses {
 			// Check for empty strings
 			if strings.TrimSpace(status) == "" {
-				return mcp.NewToolResultError("status cannot be empty"), nil
+				return toolError(CodeInvalidInput, "status cannot be empty"), nil
 			}
 
 			// Check for duplicates
 			if seenStatuses[status] {
-				return mcp.NewToolResultError(fmt.Sprintf("duplicate status: '%s'", status)), nil
+				return toolError(CodeInvalidInput, fmt.Sprintf("duplicate status: '%s'", status)), nil
 			}
 			seenStatuses[status] = true
 
 			// Check for valid status
 			if !validStatuses[status] {
-				return mcp.NewToolResultError(fmt.Sprintf("invalid status: '%s'. Valid statuses are: pending, in_progress, waiting_for_user, completed, cancelled", status)), nil
+				return toolError(CodeInvalidInput, fmt.Sprintf("invalid status: '%s'. Valid statuses are: pending, in_progress, waiting_for_user, completed, cancelled", status)), nil
 			}
 		}
 
-		// Get database connection
-		db := database.DB
-
-		// Build query
-		placeholders := make([]string, len(input.Statuses))
-		queryArgs := make([]interface{}, 0, len(input.Statuses)+1)
-		queryArgs = append(queryArgs, userID)
+		waitSeconds := 0
+		if input.WaitSeconds != nil {
+			waitSeconds = *input.WaitSeconds
+		}
+		if waitSeconds < 0 || waitSeconds > maxWaitSeconds {
+			return toolError(CodeInvalidInput, fmt.Sprintf("wait_seconds must be between 0 and %d", maxWaitSeconds)), nil
+		}
 
+		// Build filter
+		statuses := make([]models.TaskStatus, len(input.Statuses))
 		for i, status := range input.Statuses {
-			placeholders[i] = fmt.Sprintf("$%d", i+2)
-			queryArgs = append(queryArgs, status)
+			statuses[i] = models.TaskStatus(status)
 		}
-
-		query := fmt.Sprintf(`
-			SELECT 
-				t.id, t.description, t.status, 
-				t.created_by, t.assigned_to, t.result,
-				t.created_at, t.updated_at, t.completed_at,
-				creator.name as creator_name,
-				assignee.name as assignee_name
-			FROM tasks t
-			JOIN users creator ON t.created_by = creator.id
-			JOIN users assignee ON t.assigned_to = assignee.id
-			WHERE t.is_archived = false
-				AND t.status IN (%s)
-				AND t.assigned_to = $1
-			ORDER BY t.created_at ASC
-			LIMIT 1
-		`, strings.Join(placeholders, ", "))
-
-		// Execute query
-		var task models.Task
-		var creatorName, assigneeName string
-		var completedAt sql.NullTime
-		var result sql.NullString
-		var statusStr string
-
-		err = db.QueryRow(query, queryArgs...).Scan(
-			&task.ID, &task.Description, &statusStr,
-			&task.CreatedBy, &task.AssignedTo, &result,
-			&task.CreatedAt, &task.UpdatedAt, &completedAt,
-			&creatorName, &assigneeName,
-		)
-		task.Status = models.TaskStatus(statusStr)
-
-		if err == sql.ErrNoRows {
-			// No tasks found - return null
-			return mcp.NewToolResultStructured(nil, "No tasks found"), nil
+		filter := store.TaskFilter{
+			OrgID:           claims.OrgID,
+			AssignedTo:      userID,
+			Statuses:        statuses,
+			ExcludeArchived: true,
+			Limit:           1,
 		}
 
-		if err != nil {
-			log.Printf("Error querying task: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to get task: %v", err)), nil
+		// Subscribe before the first query so that no task change is missed
+		var changes <-chan struct{}
+		var timeout <-chan time.Time
+		if waitSeconds > 0 {
+			var unsubscribe func()
+			changes, unsubscribe = st.WatchTasks(userID)
+			defer unsubscribe()
+
+			timer := time.NewTimer(time.Duration(waitSeconds) * time.Second)
+			defer timer.Stop()
+			timeout = timer.C
 		}
 
-		// Prepare output
-		output := GetNextTaskOutput{
-			ID:           task.ID,
-			Description:  task.Description,
-			Status:       string(task.Status),
-			CreatedBy:    creatorName,
-			CreatedByID:  task.CreatedBy,
-			AssignedTo:   assigneeName,
-			AssignedToID: task.AssignedTo,
-			CreatedAt:    task.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:    task.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		// Execute query, re-running it whenever a task of the user changes
+		var tasks []models.TaskWithUsers
+		for {
+			tasks, err = st.ListTasks(ctx, filter)
+			if err != nil || len(tasks) > 0 || waitSeconds == 0 {
+				break
+			}
+
+			select {
+			case <-changes:
+			case <-timeout:
+				return mcp.NewToolResultStructured(models.NextTaskResponse{}, "No tasks found"), nil
+			case <-ctx.Done():
+				return toolError(CodeInternal, "request cancelled while waiting for a task"), nil
+			}
 		}
 
-		if result.Valid {
-			output.Result = &result.String
+		if err != nil {
+			log.Printf("Error querying task: %v", err)
+			return toolError(CodeInternal, fmt.Sprintf("Failed to get task: %v", err)), nil
 		}
 
-		if completedAt.Valid {
-			completedAtStr := completedAt.Time.Format("2006-01-02T15:04:05Z")
-			output.CompletedAt = &completedAtStr
+		if len(tasks) == 0 {
+			// No tasks found - task is null
+			return mcp.NewToolResultStructured(models.NextTaskResponse{}, "No tasks found"), nil
 		}
+		task := &tasks[0]
 
 		// Get comments for the task
-		commentsQuery := `
-			SELECT 
-				tc.id, tc.task_id, tc.created_by, tc.comment, tc.created_at,
-				u.name as created_by_name
-			FROM task_comments tc
-			JOIN users u ON tc.created_by = u.id
-			WHERE tc.task_id = $1
-			ORDER BY tc.created_at ASC`
-
-		commentsRows, err := db.Query(commentsQuery, task.ID)
+		task.Comments, err = st.ListComments(ctx, task.ID)
 		if err != nil {
 			log.Printf("Error querying comments: %v", err)
 			// Don't fail the whole request if comments can't be retrieved
-		} else {
-			defer commentsRows.Close()
-
-			var comments []models.TaskCommentWithUser
-			for commentsRows.Next() {
-				var comment models.TaskCommentWithUser
-				err := commentsRows.Scan(
-					&comment.ID, &comment.TaskID, &comment.CreatedBy,
-					&comment.Comment, &comment.CreatedAt, &comment.CreatedByName,
-				)
-				if err != nil {
-					log.Printf("Error scanning comment: %v", err)
-					continue
-				}
-				comments = append(comments, comment)
-			}
-			output.Comments = comments
 		}
 
-		return mcp.NewToolResultStructured(output, fmt.Sprintf("Task: %s (ID: %s, Status: %s)", output.Description, output.ID, output.Status)), nil
+		output := models.NextTaskResponse{Task: task}
+		return mcp.NewToolResultStructured(output, fmt.Sprintf("Task: %s (ID: %s, Status: %s)", task.Description, task.ID, task.Status)), nil
 	}
 
-	s.AddTool(getNextTaskTool, handler)
+	s.AddTool(getNextTaskTool, requirePermission(auth.PermTasksWork, handler))
 	log.Println("get_next_task tool registered")
 	return nil
 }
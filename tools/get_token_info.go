@@ -4,79 +4,75 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // RegisterGetTokenInfoTool registers the get_token_info tool
-func RegisterGetTokenInfoTool(s *server.MCPServer, jwtManager *auth.JWTManager) error {
+func RegisterGetTokenInfoTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	tool := mcp.NewTool("get_token_info",
-		mcp.WithDescription("Get information about the current JWT token"),
+		mcp.WithDescription("Get information about the current JWT token or API key"),
 		mcp.WithInputSchema[struct{}](),
+		mcp.WithOutputSchema[models.TokenInfoResponse](),
 	)
 
-	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract JWT token from Authorization header
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			return mcp.NewToolResultError("Authorization header is required"), nil
-		}
-
-		// Remove "Bearer " prefix if present
-		authToken := authHeader
-		if strings.HasPrefix(authHeader, "Bearer ") {
-			authToken = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-
-		// Validate JWT token
-		claims, err := jwtManager.ValidateToken(authToken)
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Get user information
+		user, err := getOrgUser(ctx, st, claims.OrgID, claims.UserID)
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("invalid token: %v", err)), nil
+			return toolError(CodeInternal, fmt.Sprintf("failed to get user information: %v", err)), nil
 		}
 
-		// Get user information
-		user, err := GetUserByID(claims.UserID)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("failed to get user information: %v", err)), nil
+		issuedAtFormatted := ""
+		if claims.IssuedAt != nil {
+			issuedAtFormatted = claims.IssuedAt.Time.Format(time.RFC1123)
 		}
 
-		// Calculate token expiration time and remaining time
-		expiresAt := claims.ExpiresAt.Time
-		remainingTime := time.Until(expiresAt)
-		issuedAt := claims.IssuedAt.Time
+		// API keys do not expire, they are revoked explicitly
+		expiresAtFormatted := "never"
+		remainingTimeFormatted := "unlimited"
+		if claims.ExpiresAt != nil {
+			// Calculate token expiration time and remaining time
+			expiresAt := claims.ExpiresAt.Time
+			remainingTime := time.Until(expiresAt)
 
-		// Format times in a human-readable format
-		expiresAtFormatted := expiresAt.Format(time.RFC1123)
-		issuedAtFormatted := issuedAt.Format(time.RFC1123)
+			// Format times in a human-readable format
+			expiresAtFormatted = expiresAt.Format(time.RFC1123)
 
-		// Format remaining time in days, hours, minutes
-		days := int(remainingTime.Hours()) / 24
-		hours := int(remainingTime.Hours()) % 24
-		minutes := int(remainingTime.Minutes()) % 60
-		remainingTimeFormatted := fmt.Sprintf("%d days, %d hours, %d minutes", days, hours, minutes)
+			// Format remaining time in days, hours, minutes
+			days := int(remainingTime.Hours()) / 24
+			hours := int(remainingTime.Hours()) % 24
+			minutes := int(remainingTime.Minutes()) % 60
+			remainingTimeFormatted = fmt.Sprintf("%d days, %d hours, %d minutes", days, hours, minutes)
+		}
 
 		// Return token information
-		result := map[string]interface{}{
-			"success": true,
-			"token_info": map[string]interface{}{
-				"user_id":        claims.UserID,
-				"user_name":      user["name"],
-				"is_admin":       claims.IsAdmin,
-				"issued_at":      issuedAtFormatted,
-				"expires_at":     expiresAtFormatted,
-				"remaining_time": remainingTimeFormatted,
+		result := models.TokenInfoResponse{
+			Success: true,
+			TokenInfo: models.TokenInfo{
+				UserID:        claims.UserID,
+				UserName:      user.Name,
+				OrgID:         claims.OrgID,
+				IsAdmin:       claims.IsAdmin,
+				Roles:         roleNames(claims.Roles),
+				Permissions:   claims.Permissions(),
+				APIKeyID:      claims.APIKeyID,
+				IssuedAt:      issuedAtFormatted,
+				ExpiresAt:     expiresAtFormatted,
+				RemainingTime: remainingTimeFormatted,
 			},
-			"message": "Token information retrieved successfully",
+			Message: "Token information retrieved successfully",
 		}
 
-		return mcp.NewToolResultStructured(result, fmt.Sprintf("Token for %s, expires %s", user["name"], expiresAtFormatted)), nil
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("Token for %s, expires %s", user.Name, expiresAtFormatted)), nil
 	}
 
-	s.AddTool(tool, handler)
+	s.AddTool(tool, authenticated(handler))
 	log.Println("get_token_info tool registered")
 	return nil
 }
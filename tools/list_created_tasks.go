@@ -2,15 +2,14 @@ package tools
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 
 	"github.com/dushes/simple-task-mcp/auth"
-	"github.com/dushes/simple-task-mcp/database"
 	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -18,43 +17,20 @@ import (
 // ListCreatedTasksInput represents the input for list_created_tasks tool
 type ListCreatedTasksInput struct {
 	UserName *string  `json:"user_name,omitempty"`
+	TeamName *string  `json:"team_name,omitempty"`
 	Limit    *int     `json:"limit,omitempty"`
 	Statuses []string `json:"statuses,omitempty"`
 }
 
-// ListCreatedTasksOutput represents the output for list_created_tasks tool
-type ListCreatedTasksOutput struct {
-	Tasks       []TaskWithUsers `json:"tasks"`
-	TotalCount  int             `json:"total_count"`
-	LimitUsed   int             `json:"limit_used"`
-	CreatedBy   string          `json:"created_by"`
-	CreatedByID string          `json:"created_by_id"`
-}
-
-// TaskWithUsers represents a task with user information
-type TaskWithUsers struct {
-	ID           string                       `json:"id"`
-	Description  string                       `json:"description"`
-	Status       string                       `json:"status"`
-	CreatedBy    string                       `json:"created_by"`
-	CreatedByID  string                       `json:"created_by_id"`
-	AssignedTo   string                       `json:"assigned_to"`
-	AssignedToID string                       `json:"assigned_to_id"`
-	Result       *string                      `json:"result,omitempty"`
-	Comments     []models.TaskCommentWithUser `json:"comments,omitempty"`
-	IsArchived   bool                         `json:"is_archived"`
-	CreatedAt    string                       `json:"created_at"`
-	UpdatedAt    string                       `json:"updated_at"`
-	CompletedAt  *string                      `json:"completed_at,omitempty"`
-	ArchivedAt   *string                      `json:"archived_at,omitempty"`
-}
-
 // RegisterListCreatedTasksTool registers the list_created_tasks tool
-func RegisterListCreatedTasksTool(s *server.MCPServer, jwtManager *auth.JWTManager) error {
+func RegisterListCreatedTasksTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
 	listCreatedTasksTool := mcp.NewTool("list_created_tasks",
-		mcp.WithDescription("Get a list of tasks created by the current user or specified user (admins only)"),
+		mcp.WithDescription("Get a list of tasks created by the current user, a specified user, or created by or assigned to the members of a team"),
 		mcp.WithString("user_name",
-			mcp.Description("Username to get tasks for. If not provided, uses current user. Only admins can specify other users."),
+			mcp.Description("Username to get tasks for. If not provided, uses current user. Viewing other users requires the tasks:read_all permission (admins, managers) or leading a team the user belongs to."),
+		),
+		mcp.WithString("team_name",
+			mcp.Description("Team to get tasks for: tasks created by or assigned to its members, or assigned to the team. Requires leading the team or the tasks:read_all permission. Cannot be combined with user_name."),
 		),
 		mcp.WithNumber("limit",
 			mcp.Description("Maximum number of tasks to return (default: 50, max: 1000)"),
@@ -63,50 +39,32 @@ func RegisterListCreatedTasksTool(s *server.MCPServer, jwtManager *auth.JWTManag
 			mcp.Description("Array of statuses to filter by. Available statuses: pending, in_progress, waiting_for_user, completed, cancelled. If not provided, returns tasks with all statuses."),
 			mcp.Items(map[string]any{"type": "string"}),
 		),
+		mcp.WithOutputSchema[models.TaskListResponse](),
 	)
 
-	handler := func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Extract JWT token from Authorization header
-		authHeader := request.Header.Get("Authorization")
-		if authHeader == "" {
-			return mcp.NewToolResultError("Authorization header is required"), nil
-		}
-
-		// Remove "Bearer " prefix if present
-		token := authHeader
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			token = authHeader[7:]
-		}
-
-		// Validate JWT token
-		claims, err := jwtManager.ValidateToken(token)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Invalid token: %v", err)), nil
-		}
-
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
 		currentUserID := claims.UserID
-		isAdmin := claims.IsAdmin
 
 		// Parse input
 		var input ListCreatedTasksInput
 		inputBytes, err := json.Marshal(request.Params.Arguments)
 		if err != nil {
 			log.Printf("Error marshaling args: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse input: %v", err)), nil
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
 		}
 		if err := json.Unmarshal(inputBytes, &input); err != nil {
 			log.Printf("Error unmarshaling input: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse input: %v", err)), nil
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
 		}
 
 		// Set default limit
 		limit := 50
 		if input.Limit != nil {
 			if *input.Limit <= 0 {
-				return mcp.NewToolResultError("limit must be positive"), nil
+				return toolError(CodeInvalidInput, "limit must be positive"), nil
 			}
 			if *input.Limit > 1000 {
-				return mcp.NewToolResultError("limit cannot exceed 1000"), nil
+				return toolError(CodeInvalidInput, "limit cannot exceed 1000"), nil
 			}
 			limit = *input.Limit
 		}
@@ -127,184 +85,121 @@ func RegisterListCreatedTasksTool(s *server.MCPServer, jwtManager *auth.JWTManag
 			for _, status := range input.Statuses {
 				// Check for empty strings
 				if strings.TrimSpace(status) == "" {
-					return mcp.NewToolResultError("status cannot be empty"), nil
+					return toolError(CodeInvalidInput, "status cannot be empty"), nil
 				}
 
 				// Check for duplicates
 				if seenStatuses[status] {
-					return mcp.NewToolResultError(fmt.Sprintf("duplicate status: '%s'", status)), nil
+					return toolError(CodeInvalidInput, fmt.Sprintf("duplicate status: '%s'", status)), nil
 				}
 				seenStatuses[status] = true
 
 				// Check for valid status
 				if !validStatuses[status] {
-					return mcp.NewToolResultError(fmt.Sprintf("invalid status: '%s'. Valid statuses are: pending, in_progress, waiting_for_user, completed, cancelled", status)), nil
+					return toolError(CodeInvalidInput, fmt.Sprintf("invalid status: '%s'. Valid statuses are: pending, in_progress, waiting_for_user, completed, cancelled", status)), nil
 				}
 			}
 		}
 
-		// Get database connection
-		db := database.DB
+		if input.UserName != nil && *input.UserName != "" && input.TeamName != nil && *input.TeamName != "" {
+			return toolError(CodeInvalidInput, "user_name and team_name cannot be combined"), nil
+		}
 
-		// Determine target user
+		// Determine target user or team
 		var targetUserID, targetUserName string
-		if input.UserName != nil && *input.UserName != "" {
-			// Check if user can view other users' tasks
-			if !isAdmin {
-				return mcp.NewToolResultError("Only admins can view tasks created by other users"), nil
+		var team *models.Team
+		if input.TeamName != nil && *input.TeamName != "" {
+			team, err = st.GetTeamByName(ctx, claims.OrgID, *input.TeamName)
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("Team not found: %s", *input.TeamName)), nil
+			}
+			if err != nil {
+				log.Printf("Error finding team: %v", err)
+				return toolError(CodeInternal, fmt.Sprintf("Failed to find team: %v", err)), nil
+			}
+
+			// Check if user can view the team's tasks
+			if !claims.HasPermission(auth.PermTasksReadAll) {
+				isLead, err := st.IsTeamLead(ctx, currentUserID, team.ID)
+				if err != nil {
+					log.Printf("Error checking team lead: %v", err)
+					return toolError(CodeInternal, "database error"), nil
+				}
+				if !isLead {
+					return toolError(CodePermissionDenied, "permission denied: only team leads can view the tasks of their team"), nil
+				}
 			}
 
+		} else if input.UserName != nil && *input.UserName != "" {
 			// Find user by name
-			err := db.QueryRow("SELECT id, name FROM users WHERE name = $1", *input.UserName).Scan(&targetUserID, &targetUserName)
-			if err == sql.ErrNoRows {
-				return mcp.NewToolResultError(fmt.Sprintf("User not found: %s", *input.UserName)), nil
+			user, err := st.GetUserByName(ctx, claims.OrgID, *input.UserName)
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("User not found: %s", *input.UserName)), nil
 			}
 			if err != nil {
 				log.Printf("Error finding user: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to find user: %v", err)), nil
+				return toolError(CodeInternal, fmt.Sprintf("Failed to find user: %v", err)), nil
+			}
+			targetUserID, targetUserName = user.ID, user.Name
+
+			// Check if user can view other users' tasks
+			if targetUserID != currentUserID && !claims.HasPermission(auth.PermTasksReadAll) {
+				isLead, err := st.LeadsTeamOf(ctx, currentUserID, targetUserID)
+				if err != nil {
+					log.Printf("Error checking team lead: %v", err)
+					return toolError(CodeInternal, "database error"), nil
+				}
+				if !isLead {
+					return errorResult(auth.Authorize(claims, auth.PermTasksReadAll), CodePermissionDenied), nil
+				}
 			}
 		} else {
 			// Use current user
-			targetUserID = currentUserID
-			err := db.QueryRow("SELECT name FROM users WHERE id = $1", currentUserID).Scan(&targetUserName)
+			user, err := st.GetUser(ctx, currentUserID)
 			if err != nil {
 				log.Printf("Error getting current user name: %v", err)
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to get current user: %v", err)), nil
+				return toolError(CodeInternal, fmt.Sprintf("Failed to get current user: %v", err)), nil
 			}
+			targetUserID, targetUserName = user.ID, user.Name
 		}
 
-		// Build status filter for SQL queries
-		var statusFilter string
-		var countArgs []interface{}
-		var queryArgs []interface{}
-
-		countArgs = append(countArgs, targetUserID)
-		queryArgs = append(queryArgs, targetUserID)
-
-		if len(input.Statuses) > 0 {
-			placeholders := make([]string, len(input.Statuses))
-			for i, status := range input.Statuses {
-				placeholders[i] = fmt.Sprintf("$%d", i+2)
-				countArgs = append(countArgs, status)
-			}
-			statusFilter = fmt.Sprintf(" AND status IN (%s)", strings.Join(placeholders, ", "))
-
-			// For main query, we need to adjust parameter numbers
-			for i, status := range input.Statuses {
-				placeholders[i] = fmt.Sprintf("$%d", i+2)
-				queryArgs = append(queryArgs, status)
-			}
+		// Build filter
+		filter := store.TaskFilter{OrgID: claims.OrgID, NewestFirst: true}
+		if team != nil {
+			filter.TeamID = team.ID
+		} else {
+			filter.CreatedBy = targetUserID
+		}
+		for _, status := range input.Statuses {
+			filter.Statuses = append(filter.Statuses, models.TaskStatus(status))
 		}
 
 		// Get total count
-		var totalCount int
-		countQuery := fmt.Sprintf(`
-			SELECT COUNT(*) 
-			FROM tasks 
-			WHERE created_by = $1%s
-		`, statusFilter)
-
-		err = db.QueryRow(countQuery, countArgs...).Scan(&totalCount)
+		totalCount, err := st.CountTasks(ctx, filter)
 		if err != nil {
 			log.Printf("Error counting tasks: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to count tasks: %v", err)), nil
+			return toolError(CodeInternal, fmt.Sprintf("Failed to count tasks: %v", err)), nil
 		}
 
 		// Get tasks
-		limitParamNum := len(queryArgs) + 1
-		queryArgs = append(queryArgs, limit)
-
-		query := fmt.Sprintf(`
-			SELECT 
-				t.id, t.description, t.status, 
-				t.created_by, t.assigned_to, t.result,
-				t.is_archived, t.created_at, t.updated_at, 
-				t.completed_at, t.archived_at,
-				creator.name as creator_name,
-				assignee.name as assignee_name
-			FROM tasks t
-			JOIN users creator ON t.created_by = creator.id
-			JOIN users assignee ON t.assigned_to = assignee.id
-			WHERE t.created_by = $1%s
-			ORDER BY t.created_at DESC
-			LIMIT $%d`, statusFilter, limitParamNum)
-
-		rows, err := db.Query(query, queryArgs...)
+		filter.Limit = limit
+		tasks, err := st.ListTasks(ctx, filter)
 		if err != nil {
 			log.Printf("Error querying tasks: %v", err)
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to get tasks: %v", err)), nil
+			return toolError(CodeInternal, fmt.Sprintf("Failed to get tasks: %v", err)), nil
 		}
-		defer rows.Close()
 
-		var tasks []TaskWithUsers
-		for rows.Next() {
-			var task TaskWithUsers
-			var completedAt, archivedAt sql.NullTime
-			var result sql.NullString
-
-			err := rows.Scan(
-				&task.ID, &task.Description, &task.Status,
-				&task.CreatedByID, &task.AssignedToID, &result,
-				&task.IsArchived, &task.CreatedAt, &task.UpdatedAt,
-				&completedAt, &archivedAt,
-				&task.CreatedBy, &task.AssignedTo,
-			)
+		// Get comments for the tasks
+		for i := range tasks {
+			tasks[i].Comments, err = st.ListComments(ctx, tasks[i].ID)
 			if err != nil {
-				log.Printf("Error scanning task: %v", err)
-				continue
-			}
-
-			if result.Valid {
-				task.Result = &result.String
-			}
-
-			if completedAt.Valid {
-				completedAtStr := completedAt.Time.Format("2006-01-02T15:04:05Z")
-				task.CompletedAt = &completedAtStr
-			}
-
-			if archivedAt.Valid {
-				archivedAtStr := archivedAt.Time.Format("2006-01-02T15:04:05Z")
-				task.ArchivedAt = &archivedAtStr
-			}
-
-			// Get comments for the task
-			commentsQuery := `
-				SELECT 
-					tc.id, tc.task_id, tc.created_by, tc.comment, tc.created_at,
-					u.name as created_by_name
-				FROM task_comments tc
-				JOIN users u ON tc.created_by = u.id
-				WHERE tc.task_id = $1
-				ORDER BY tc.created_at ASC`
-
-			commentsRows, err := db.Query(commentsQuery, task.ID)
-			if err != nil {
-				log.Printf("Error querying comments for task %s: %v", task.ID, err)
+				log.Printf("Error querying comments for task %s: %v", tasks[i].ID, err)
 				// Don't fail the whole request if comments can't be retrieved
-			} else {
-				var comments []models.TaskCommentWithUser
-				for commentsRows.Next() {
-					var comment models.TaskCommentWithUser
-					err := commentsRows.Scan(
-						&comment.ID, &comment.TaskID, &comment.CreatedBy,
-						&comment.Comment, &comment.CreatedAt, &comment.CreatedByName,
-					)
-					if err != nil {
-						log.Printf("Error scanning comment: %v", err)
-						continue
-					}
-					comments = append(comments, comment)
-				}
-				task.Comments = comments
-				commentsRows.Close()
 			}
-
-			tasks = append(tasks, task)
 		}
 
 		// Prepare output
-		output := ListCreatedTasksOutput{
+		output := models.TaskListResponse{
 			Tasks:       tasks,
 			TotalCount:  totalCount,
 			LimitUsed:   limit,
@@ -312,10 +207,18 @@ func RegisterListCreatedTasksTool(s *server.MCPServer, jwtManager *auth.JWTManag
 			CreatedByID: targetUserID,
 		}
 
+		if team != nil {
+			output.CreatedBy = ""
+			output.CreatedByID = ""
+			output.Team = team.Name
+			output.TeamID = team.ID
+			return mcp.NewToolResultStructured(output, fmt.Sprintf("Found %d tasks for team %s", output.TotalCount, output.Team)), nil
+		}
+
 		return mcp.NewToolResultStructured(output, fmt.Sprintf("Found %d tasks created by %s", output.TotalCount, output.CreatedBy)), nil
 	}
 
-	s.AddTool(listCreatedTasksTool, handler)
+	s.AddTool(listCreatedTasksTool, requirePermission(auth.PermTasksRead, handler))
 	log.Println("list_created_tasks tool registered")
 	return nil
 }
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+func TestListCreatedTasksTeamLead(t *testing.T) {
+	s := newTestServer(t, RegisterListCreatedTasksTool)
+	_, leadToken := s.addUser(t, "lead")
+	bob, _ := s.addUser(t, "bob")
+	_, memberToken := s.addUser(t, "member")
+	dave, daveToken := s.addUser(t, "dave")
+
+	team := models.Team{OrgID: s.orgID, Name: "ops"}
+	if err := s.st.CreateTeam(context.Background(), &team); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	for name, isLead := range map[string]bool{"lead": true, "bob": false, "member": false} {
+		user, err := s.st.GetUserByName(context.Background(), s.orgID, name)
+		if err != nil {
+			t.Fatalf("GetUserByName: %v", err)
+		}
+		if err := s.st.SetTeamMember(context.Background(), team.ID, user.ID, isLead); err != nil {
+			t.Fatalf("SetTeamMember: %v", err)
+		}
+	}
+
+	createdByBob := s.addTask(t, bob.ID, dave.ID, models.StatusPending)
+	assignedToBob := s.addTask(t, dave.ID, bob.ID, models.StatusPending)
+	s.addTask(t, dave.ID, dave.ID, models.StatusPending)
+
+	taskIDs := func(response models.TaskListResponse) []string {
+		var ids []string
+		for _, task := range response.Tasks {
+			ids = append(ids, task.ID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	// The lead sees the tasks created by or assigned to the team's members
+	var response models.TaskListResponse
+	decodeResult(t, s.callTool(t, leadToken, "list_created_tasks", map[string]any{"team_name": "ops"}), &response)
+	want := []string{createdByBob, assignedToBob}
+	slices.Sort(want)
+	if ids := taskIDs(response); !slices.Equal(ids, want) || response.Team != "ops" {
+		t.Errorf("team tasks = %v of team %q, want %v", ids, response.Team, want)
+	}
+
+	// and the tasks created by a member
+	decodeResult(t, s.callTool(t, leadToken, "list_created_tasks", map[string]any{"user_name": "bob"}), &response)
+	if ids := taskIDs(response); !slices.Equal(ids, []string{createdByBob}) || response.CreatedBy != "bob" {
+		t.Errorf("tasks of bob = %v created by %q, want %v", ids, response.CreatedBy, []string{createdByBob})
+	}
+
+	// Members who do not lead the team and outsiders may not
+	for name, token := range map[string]string{"member": memberToken, "outsider": daveToken} {
+		for _, args := range []map[string]any{{"team_name": "ops"}, {"user_name": "bob"}} {
+			result := s.callTool(t, token, "list_created_tasks", args)
+			if !result.IsError || ResultErrorCode(result) != CodePermissionDenied {
+				t.Errorf("%s listing %v: error = %v, code = %s, want permission_denied", name, args, result.IsError, ResultErrorCode(result))
+			}
+		}
+	}
+}
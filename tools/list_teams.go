@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterListTeamsTool registers the list_teams tool
+func RegisterListTeamsTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("list_teams",
+		mcp.WithDescription("List all teams with their members and leads"),
+		mcp.WithInputSchema[struct{}](),
+		mcp.WithOutputSchema[models.TeamListResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		teams, err := st.ListTeams(ctx, claims.OrgID)
+		if err != nil {
+			log.Printf("Error querying teams: %v", err)
+			return toolError(CodeInternal, "Failed to query teams"), nil
+		}
+
+		result := models.TeamListResponse{
+			Teams: teams,
+			Count: len(teams),
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("Found %d teams", len(teams))), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersRead, handler))
+	log.Println("list_teams tool registered")
+	return nil
+}
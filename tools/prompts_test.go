@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// getPrompt gets a prompt with the token and returns it, or the error message
+func (s *testServer) getPrompt(t *testing.T, token, name string, args map[string]string) (*mcp.GetPromptResult, string) {
+	t.Helper()
+	data, message := s.send(t, token, string(mcp.MethodPromptsGet), mcp.GetPromptParams{Name: name, Arguments: args})
+	if message != "" {
+		return nil, message
+	}
+	result, err := mcp.ParseGetPromptResult((*json.RawMessage)(&data))
+	if err != nil {
+		t.Fatalf("decode prompt: %v", err)
+	}
+	return result, ""
+}
+
+func TestPromptsRegistered(t *testing.T) {
+	s := newTestServer(t, RegisterWorkOnNextTaskPrompt, RegisterTriageCreatedTasksPrompt, RegisterSummarizeTaskHistoryPrompt)
+	_, token := s.addUser(t, "alice")
+
+	data, message := s.send(t, token, string(mcp.MethodPromptsList), nil)
+	if message != "" {
+		t.Fatalf("prompts/list: %s", message)
+	}
+	var list mcp.ListPromptsResult
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatalf("decode prompts: %v", err)
+	}
+
+	arguments := map[string][]mcp.PromptArgument{}
+	for _, prompt := range list.Prompts {
+		arguments[prompt.Name] = prompt.Arguments
+	}
+	if len(arguments) != 3 {
+		t.Errorf("prompts = %v, want work_on_next_task, triage_created_tasks and summarize_task_history", arguments)
+	}
+	if args := arguments["work_on_next_task"]; len(args) != 1 || args[0].Name != "status" || args[0].Required {
+		t.Errorf("work_on_next_task arguments = %+v, want an optional status", args)
+	}
+	if args, ok := arguments["triage_created_tasks"]; !ok || len(args) != 0 {
+		t.Errorf("triage_created_tasks arguments = %+v, want none", args)
+	}
+	if args := arguments["summarize_task_history"]; len(args) != 1 || args[0].Name != "task_id" || !args[0].Required {
+		t.Errorf("summarize_task_history arguments = %+v, want a required task_id", args)
+	}
+}
+
+func TestPromptOutput(t *testing.T) {
+	s := newTestServer(t, RegisterWorkOnNextTaskPrompt, RegisterTriageCreatedTasksPrompt, RegisterSummarizeTaskHistoryPrompt)
+	alice, aliceToken := s.addUser(t, "alice")
+	bob, bobToken := s.addUser(t, "bob")
+	_, carolToken := s.addUser(t, "carol")
+	_, viewerToken := s.addUser(t, "viewer", "viewer")
+
+	// Nothing to work on yet
+	result, message := s.getPrompt(t, bobToken, "work_on_next_task", nil)
+	if message != "" || result.Description != "No task to work on" {
+		t.Errorf("work_on_next_task without tasks = %+v (%s)", result, message)
+	}
+
+	taskID := s.addTask(t, alice.ID, bob.ID, models.StatusPending)
+	waiting := s.addTask(t, alice.ID, bob.ID, models.StatusWaitingForUser)
+
+	// The task is embedded as its resource after the instructions
+	embeddedTask := func(name string, result *mcp.GetPromptResult, wantID string) {
+		t.Helper()
+		if len(result.Messages) != 2 {
+			t.Fatalf("%s: %d messages, want instructions and the task", name, len(result.Messages))
+		}
+		resource, ok := result.Messages[1].Content.(mcp.EmbeddedResource)
+		if !ok {
+			t.Fatalf("%s: second message is %T, want the embedded task", name, result.Messages[1].Content)
+		}
+		if contents, ok := resource.Resource.(mcp.TextResourceContents); !ok || contents.URI != "task://"+wantID {
+			t.Errorf("%s: embedded %+v, want task://%s", name, resource.Resource, wantID)
+		}
+	}
+
+	result, message = s.getPrompt(t, bobToken, "work_on_next_task", nil)
+	if message != "" {
+		t.Fatalf("work_on_next_task: %s", message)
+	}
+	embeddedTask("work_on_next_task", result, taskID)
+	if text, ok := result.Messages[0].Content.(mcp.TextContent); !ok || !strings.Contains(text.Text, taskID) || !strings.Contains(text.Text, "alice") {
+		t.Errorf("work_on_next_task instructions = %+v, want the task ID and its creator", result.Messages[0].Content)
+	}
+
+	result, message = s.getPrompt(t, bobToken, "work_on_next_task", map[string]string{"status": "waiting_for_user"})
+	if message != "" {
+		t.Fatalf("work_on_next_task waiting_for_user: %s", message)
+	}
+	embeddedTask("work_on_next_task waiting_for_user", result, waiting)
+
+	result, message = s.getPrompt(t, aliceToken, "triage_created_tasks", nil)
+	if message != "" {
+		t.Fatalf("triage_created_tasks: %s", message)
+	}
+	if text, ok := result.Messages[0].Content.(mcp.TextContent); !ok || !strings.Contains(text.Text, taskID) || !strings.Contains(text.Text, waiting) {
+		t.Errorf("triage_created_tasks = %+v, want both open tasks", result.Messages[0].Content)
+	}
+
+	result, message = s.getPrompt(t, aliceToken, "summarize_task_history", map[string]string{"task_id": taskID})
+	if message != "" {
+		t.Fatalf("summarize_task_history: %s", message)
+	}
+	embeddedTask("summarize_task_history", result, taskID)
+
+	// Prompts check permissions and task visibility like the tools
+	if _, message := s.getPrompt(t, viewerToken, "work_on_next_task", nil); message == "" {
+		t.Errorf("viewer got work_on_next_task, want permission denied")
+	}
+	if _, message := s.getPrompt(t, carolToken, "summarize_task_history", map[string]string{"task_id": taskID}); message == "" {
+		t.Errorf("carol got the history of a task of alice and bob")
+	}
+	if _, message := s.getPrompt(t, bobToken, "work_on_next_task", map[string]string{"status": "done"}); message == "" {
+		t.Errorf("work_on_next_task accepted an invalid status")
+	}
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterRemoveTeamMemberTool registers the remove_team_member tool
+func RegisterRemoveTeamMemberTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("remove_team_member",
+		mcp.WithDescription("Remove a user from a team (admin only)"),
+		mcp.WithString("team_name",
+			mcp.Required(),
+			mcp.Description("Name of the team"),
+		),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the member"),
+		),
+		mcp.WithOutputSchema[models.TeamMembershipResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		teamName, err := request.RequireString("team_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "team_name is required"), nil
+		}
+		userName, err := request.RequireString("user_name")
+		if err != nil {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+
+		team, err := st.GetTeamByName(ctx, claims.OrgID, teamName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("team '%s' does not exist", teamName)), nil
+			}
+			log.Printf("Error finding team by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		notMember := fmt.Sprintf("user '%s' is not a member of team '%s'", userName, team.Name)
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err == store.ErrNotFound {
+			return toolError(CodeNotFound, notMember), nil
+		}
+		if err != nil {
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		result := models.TeamMembershipResponse{TeamID: team.ID, TeamName: team.Name, UserID: user.ID, UserName: userName, Removed: true}
+		result.IsLead, err = st.RemoveTeamMember(ctx, team.ID, user.ID)
+		if err == store.ErrNotFound {
+			return toolError(CodeNotFound, notMember), nil
+		}
+		if err != nil {
+			log.Printf("Error removing team member: %v", err)
+			return toolError(CodeInternal, "failed to remove team member"), nil
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("%s removed from team %s", userName, team.Name)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("remove_team_member tool registered")
+	return nil
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxProgressMessageLength limits the status message of a progress report
+const maxProgressMessageLength = 500
+
+// ReportProgressInput represents the input for report_progress tool
+type ReportProgressInput struct {
+	ID      string `json:"id"`
+	Percent *int   `json:"percent"`
+	Message string `json:"message,omitempty"`
+}
+
+// RegisterReportProgressTool registers the report_progress tool
+func RegisterReportProgressTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	reportProgressTool := mcp.NewTool("report_progress",
+		mcp.WithDescription("Report progress on a task assigned to you. Moves a pending task to in_progress; a task waiting for user keeps waiting for the answer."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Task ID (UUID)"),
+		),
+		mcp.WithNumber("percent",
+			mcp.Required(),
+			mcp.Description("Percent complete (0-100)"),
+			mcp.Min(0),
+			mcp.Max(100),
+		),
+		mcp.WithString("message",
+			mcp.Description(fmt.Sprintf("Short status message (max %d characters)", maxProgressMessageLength)),
+		),
+		mcp.WithOutputSchema[models.TaskWithUsers](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Parse input
+		var input ReportProgressInput
+		inputBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			log.Printf("Error marshaling args: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+		if err := json.Unmarshal(inputBytes, &input); err != nil {
+			log.Printf("Error unmarshaling input: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+
+		// Validate required parameters
+		if input.ID == "" {
+			return toolError(CodeInvalidInput, "task ID is required"), nil
+		}
+		if input.Percent == nil {
+			return toolError(CodeInvalidInput, "percent is required"), nil
+		}
+		if *input.Percent < 0 || *input.Percent > 100 {
+			return toolError(CodeInvalidInput, "percent must be between 0 and 100"), nil
+		}
+		if len([]rune(input.Message)) > maxProgressMessageLength {
+			return toolError(CodeInvalidInput, fmt.Sprintf("message must be at most %d characters", maxProgressMessageLength)), nil
+		}
+
+		// Validate UUID format
+		if !isValidUUID(input.ID) {
+			return toolError(CodeInvalidInput, "invalid task ID format"), nil
+		}
+
+		// Check if task exists and the user is its assignee
+		task, err := st.GetTask(ctx, claims.OrgID, input.ID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, "task not found"), nil
+			}
+			log.Printf("Error checking task: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		if task.AssignedToID != claims.UserID {
+			return toolError(CodePermissionDenied, "permission denied: you can only report progress on tasks assigned to you"), nil
+		}
+
+		if task.IsArchived {
+			return toolError(CodeConflict, "cannot report progress on archived task"), nil
+		}
+
+		if task.Status == string(models.StatusCompleted) || task.Status == string(models.StatusCancelled) {
+			return toolError(CodeConflict, fmt.Sprintf("cannot report progress on %s task", task.Status)), nil
+		}
+
+		// Store the report on the task and in its history
+		err = st.ReportProgress(ctx, input.ID, claims.UserID, *input.Percent, input.Message)
+		if err == store.ErrTaskClosed {
+			return toolError(CodeConflict, "task was completed or cancelled in the meantime"), nil
+		}
+		if err != nil {
+			log.Printf("Error reporting task progress: %v", err)
+			return toolError(CodeInternal, "failed to report progress"), nil
+		}
+
+		// Get task details with user names for response
+		task, err = st.GetTask(ctx, claims.OrgID, input.ID)
+		if err != nil {
+			log.Printf("Error getting task details: %v", err)
+			return toolError(CodeInternal, "failed to get updated task details"), nil
+		}
+
+		// Push the progress to subscribers of the task
+		notifyTaskChanged(ctx, st, taskChange{OrgID: claims.OrgID, TaskID: task.ID, AssigneeName: task.AssignedTo, Progress: task.Progress})
+
+		return mcp.NewToolResultStructured(task, fmt.Sprintf("Progress reported: %d%% on %s (ID: %s)", *input.Percent, task.Description, task.ID)), nil
+	}
+
+	s.AddTool(reportProgressTool, requirePermission(auth.PermTasksWork, handler))
+	log.Println("report_progress tool registered")
+	return nil
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RespondToTaskInput represents the input for respond_to_task tool
+type RespondToTaskInput struct {
+	ID      string `json:"id"`
+	Comment string `json:"comment"`
+}
+
+// RegisterRespondToTaskTool registers the respond_to_task tool
+func RegisterRespondToTaskTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	respondToTaskTool := mcp.NewTool("respond_to_task",
+		mcp.WithDescription("Answer a task that is waiting for user with a comment and send it back to pending, so that its assignee picks it up again"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Task ID (UUID)"),
+		),
+		mcp.WithString("comment",
+			mcp.Required(),
+			mcp.Description("Answer to the question asked with wait_for_user"),
+		),
+		mcp.WithOutputSchema[models.TaskWithComment](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Parse input
+		var input RespondToTaskInput
+		inputBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			log.Printf("Error marshaling args: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+		if err := json.Unmarshal(inputBytes, &input); err != nil {
+			log.Printf("Error unmarshaling input: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+
+		// Validate required parameters
+		if input.ID == "" {
+			return toolError(CodeInvalidInput, "task ID is required"), nil
+		}
+		if input.Comment == "" {
+			return toolError(CodeInvalidInput, "comment is required"), nil
+		}
+
+		// Validate UUID format
+		if !isValidUUID(input.ID) {
+			return toolError(CodeInvalidInput, "invalid task ID format"), nil
+		}
+
+		// Check if task exists and user has permission to modify it
+		task, err := st.GetTask(ctx, claims.OrgID, input.ID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, "task not found"), nil
+			}
+			log.Printf("Error checking task: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		// Check if user has permission (must be creator, assignee or their team lead)
+		canManage, err := canManageTask(ctx, st, claims, task.CreatedByID, task.AssignedToID)
+		if err != nil {
+			log.Printf("Error checking task permission: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+		if !canManage {
+			return toolError(CodePermissionDenied, "permission denied: you can only modify tasks you created, are assigned to, or that belong to your team"), nil
+		}
+
+		// Check if task is already archived
+		if task.IsArchived {
+			return toolError(CodeConflict, "cannot modify archived task"), nil
+		}
+
+		// Only a task waiting for user has a question to answer
+		if task.Status != string(models.StatusWaitingForUser) {
+			return toolError(CodeConflict, fmt.Sprintf("task is not waiting for user (status: %s)", task.Status)), nil
+		}
+
+		// Update task status to pending and add the comment together
+		comment, err := st.RespondToTask(ctx, input.ID, claims.UserID, input.Comment)
+		if err != nil {
+			log.Printf("Error responding to task: %v", err)
+			return toolError(CodeInternal, "failed to update task status"), nil
+		}
+
+		// Get task details with user names for response
+		task, err = st.GetTask(ctx, claims.OrgID, input.ID)
+		if err != nil {
+			log.Printf("Error getting task details: %v", err)
+			return toolError(CodeInternal, "failed to get updated task details"), nil
+		}
+
+		response := models.TaskWithComment{
+			TaskWithUsers: *task,
+			CommentAdded:  *comment,
+		}
+
+		// Push the status change to subscribers of the task
+		notifyTaskChanged(ctx, st, taskChange{OrgID: claims.OrgID, TaskID: task.ID, AssigneeName: task.AssignedTo, CommentAdded: true})
+
+		return mcp.NewToolResultStructured(response, fmt.Sprintf("Responded to task: %s (ID: %s)", task.Description, task.ID)), nil
+	}
+
+	s.AddTool(respondToTaskTool, requirePermission(auth.PermTasksWork, handler))
+	log.Println("respond_to_task tool registered")
+	return nil
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/google/uuid"
+)
+
+func TestRespondToTask(t *testing.T) {
+	s := newTestServer(t, RegisterRespondToTaskTool)
+	creator, creatorToken := s.addUser(t, "creator")
+	assignee, _ := s.addUser(t, "assignee")
+	taskID := s.addTask(t, creator.ID, assignee.ID, models.StatusWaitingForUser)
+
+	result := s.callTool(t, creatorToken, "respond_to_task", map[string]any{"id": taskID, "comment": "use the staging database"})
+	var response models.TaskWithComment
+	decodeResult(t, result, &response)
+
+	if response.Status != string(models.StatusPending) {
+		t.Errorf("status = %s, want %s", response.Status, models.StatusPending)
+	}
+	if response.AssignedTo != "assignee" {
+		t.Errorf("assigned to %s, want assignee", response.AssignedTo)
+	}
+	if response.CommentAdded.Comment != "use the staging database" || response.CommentAdded.CreatedByName != "creator" {
+		t.Errorf("comment added = %+v", response.CommentAdded)
+	}
+
+	comments, err := s.st.ListComments(context.Background(), taskID)
+	if err != nil {
+		t.Fatalf("ListComments: %v", err)
+	}
+	if len(comments) != 1 {
+		t.Fatalf("%d comments stored, want 1", len(comments))
+	}
+}
+
+func TestRespondToTaskErrors(t *testing.T) {
+	s := newTestServer(t, RegisterRespondToTaskTool)
+	creator, creatorToken := s.addUser(t, "creator")
+	assignee, _ := s.addUser(t, "assignee")
+	_, otherToken := s.addUser(t, "other")
+	_, viewerToken := s.addUser(t, "viewer", auth.RoleViewer)
+
+	waiting := s.addTask(t, creator.ID, assignee.ID, models.StatusWaitingForUser)
+	pending := s.addTask(t, creator.ID, assignee.ID, models.StatusPending)
+
+	tests := []struct {
+		name  string
+		token string
+		args  map[string]any
+		code  ErrorCode
+	}{
+		{"missing comment", creatorToken, map[string]any{"id": waiting}, CodeInvalidInput},
+		{"invalid ID", creatorToken, map[string]any{"id": "42", "comment": "yes"}, CodeInvalidInput},
+		{"unknown task", creatorToken, map[string]any{"id": uuid.New().String(), "comment": "yes"}, CodeNotFound},
+		{"not waiting", creatorToken, map[string]any{"id": pending, "comment": "yes"}, CodeConflict},
+		{"unrelated user", otherToken, map[string]any{"id": waiting, "comment": "yes"}, CodePermissionDenied},
+		{"viewer", viewerToken, map[string]any{"id": waiting, "comment": "yes"}, CodePermissionDenied},
+		{"no token", "", map[string]any{"id": waiting, "comment": "yes"}, CodeUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.callTool(t, tt.token, "respond_to_task", tt.args)
+			if !result.IsError {
+				t.Fatal("tool succeeded")
+			}
+			if code := ResultErrorCode(result); code != tt.code {
+				t.Errorf("code = %s, want %s (%v)", code, tt.code, result.Content)
+			}
+		})
+	}
+
+	// The failed calls left the task waiting
+	task, err := s.st.GetTask(context.Background(), s.orgID, waiting)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if task.Status != string(models.StatusWaitingForUser) {
+		t.Errorf("status = %s, want %s", task.Status, models.StatusWaitingForUser)
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterRevokeAPIKeyTool registers the revoke_api_key tool
+func RegisterRevokeAPIKeyTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("revoke_api_key",
+		mcp.WithDescription("Revoke an API key. Users can revoke their own keys, admins can revoke any key."),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("API key ID (UUID)"),
+		),
+		mcp.WithOutputSchema[models.RevokedAPIKeyResponse](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Extract parameters
+		keyID, err := request.RequireString("id")
+		if err != nil {
+			return toolError(CodeInvalidInput, "id is required"), nil
+		}
+
+		// Validate UUID format
+		if !isValidUUID(keyID) {
+			return toolError(CodeInvalidInput, "invalid API key ID format"), nil
+		}
+
+		// Check if key exists and user has permission to revoke it
+		key, err := st.GetAPIKey(ctx, keyID)
+		if err == nil {
+			// Keys of other organizations do not exist for the caller
+			var owner *models.UserWithRoles
+			owner, err = st.GetUser(ctx, key.UserID)
+			if err == nil && owner.OrgID != claims.OrgID {
+				err = store.ErrNotFound
+			}
+		}
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, "API key not found"), nil
+			}
+			log.Printf("Error checking API key: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+		ownerID, name := key.UserID, key.Name
+
+		if ownerID != claims.UserID && !claims.HasPermission(auth.PermUsersManage) {
+			return toolError(CodePermissionDenied, "permission denied: you can only revoke your own API keys"), nil
+		}
+
+		if key.RevokedAt.Valid {
+			return toolError(CodeConflict, "API key is already revoked"), nil
+		}
+
+		revokedAt, err := st.RevokeAPIKey(ctx, keyID)
+		if err != nil {
+			log.Printf("Error revoking API key: %v", err)
+			return toolError(CodeInternal, "failed to revoke API key"), nil
+		}
+
+		result := models.RevokedAPIKeyResponse{
+			ID:        keyID,
+			Name:      name,
+			UserID:    ownerID,
+			RevokedAt: revokedAt.Format("2006-01-02T15:04:05Z"),
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("API key revoked: %s (ID: %s)", name, keyID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermAPIKeysOwn, handler))
+	log.Println("revoke_api_key tool registered")
+	return nil
+}
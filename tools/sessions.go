@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionCredentials maps session IDs to the credential presented when the
+// session was initialized. The credential, not the claims, is stored so that
+// it is validated again on every call and revocation takes effect at once.
+type sessionCredentials struct {
+	mu          sync.RWMutex
+	credentials map[string]string
+}
+
+// boundCredentials is the registry shared by the session hooks and authenticate
+var boundCredentials = &sessionCredentials{credentials: make(map[string]string)}
+
+// Hooks returns the server hooks used by the tools: binding authenticated
+// users to stateful sessions and tracking resource subscriptions
+func Hooks(jwtManager *auth.JWTManager, st store.Store) *server.Hooks {
+	hooks := &server.Hooks{}
+	addSessionHooks(hooks, jwtManager, st)
+	addSubscriptionHooks(hooks, jwtManager, st)
+	return hooks
+}
+
+// addSessionHooks binds the credential of a successful initialize request to
+// the session. Only sessions with an ID are bound: stateless HTTP sessions
+// have none, so every stateless request must carry its own credential.
+func addSessionHooks(hooks *server.Hooks, jwtManager *auth.JWTManager, st store.Store) {
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, request *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil || session.SessionID() == "" {
+			return
+		}
+
+		credential := credentialFromHeader(request.Header)
+		if credential == "" {
+			return
+		}
+
+		claims, err := authenticate(ctx, request.Header, jwtManager, st)
+		if err != nil {
+			log.Printf("Session %s not bound: %v", session.SessionID(), err)
+			return
+		}
+
+		boundCredentials.bind(session.SessionID(), credential)
+		log.Printf("Session %s bound to user %s", session.SessionID(), claims.UserID)
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		boundCredentials.unbind(session.SessionID())
+	})
+}
+
+// credentialFromHeader returns the API key or bearer token of the request
+func credentialFromHeader(header http.Header) string {
+	if credential := header.Get("X-API-Key"); credential != "" {
+		return credential
+	}
+	return strings.TrimPrefix(header.Get("Authorization"), "Bearer ")
+}
+
+// credentialFromSession returns the credential bound to the caller's session
+func credentialFromSession(ctx context.Context) string {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil || session.SessionID() == "" {
+		return ""
+	}
+	return boundCredentials.get(session.SessionID())
+}
+
+// bind records the credential of the session
+func (c *sessionCredentials) bind(sessionID, credential string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.credentials[sessionID] = credential
+}
+
+// unbind forgets the credential of a closed session
+func (c *sessionCredentials) unbind(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.credentials, sessionID)
+}
+
+// get returns the credential bound to the session, if any
+func (c *sessionCredentials) get(sessionID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.credentials[sessionID]
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// initializeHTTP initializes a session with the headers and returns its ID
+func initializeHTTP(t *testing.T, url string, header http.Header) string {
+	t.Helper()
+	_, responseHeader := postHTTP(t, url, header, string(mcp.MethodInitialize), mcp.InitializeParams{
+		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+		ClientInfo:      mcp.Implementation{Name: "test", Version: "0.0.0"},
+	})
+	return responseHeader.Get(server.HeaderKeySessionID)
+}
+
+// callerOf returns the user a tool call over HTTP runs as, or "" if it was
+// rejected as unauthenticated
+func callerOf(t *testing.T, url string, header http.Header) string {
+	t.Helper()
+	result := callToolHTTP(t, url, header, "get_token_info", nil)
+	if result.IsError {
+		if code := ResultErrorCode(result); code != CodeUnauthenticated {
+			t.Fatalf("get_token_info: code = %s, want unauthenticated", code)
+		}
+		return ""
+	}
+	var info models.TokenInfoResponse
+	decodeResult(t, result, &info)
+	return info.TokenInfo.UserName
+}
+
+func TestSessionCredentialBinding(t *testing.T) {
+	s := newTestServer(t, RegisterGetTokenInfoTool)
+	url := s.serveHTTP(t, server.WithStateful(true))
+	alice, aliceToken := s.addUser(t, "alice")
+	_, bobToken := s.addUser(t, "bob")
+
+	bearer := func(token string) http.Header {
+		return http.Header{"Authorization": {"Bearer " + token}}
+	}
+	inSession := func(sessionID string, header http.Header) http.Header {
+		header = header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set(server.HeaderKeySessionID, sessionID)
+		return header
+	}
+
+	session := initializeHTTP(t, url, bearer(aliceToken))
+	if session == "" {
+		t.Fatal("initialize returned no session ID")
+	}
+
+	// Later requests of the session need no credential
+	if caller := callerOf(t, url, inSession(session, nil)); caller != "alice" {
+		t.Errorf("session call runs as %q, want alice", caller)
+	}
+
+	// A credential sent with a request takes precedence for that request only
+	if caller := callerOf(t, url, inSession(session, bearer(bobToken))); caller != "bob" {
+		t.Errorf("session call with bob's token runs as %q, want bob", caller)
+	}
+	if caller := callerOf(t, url, inSession(session, nil)); caller != "alice" {
+		t.Errorf("session call after bob's request runs as %q, want alice", caller)
+	}
+
+	// A new session is bound to the credential it was initialized with
+	bobSession := initializeHTTP(t, url, bearer(bobToken))
+	if caller := callerOf(t, url, inSession(bobSession, nil)); caller != "bob" {
+		t.Errorf("bob's session runs as %q, want bob", caller)
+	}
+
+	// Sessions initialized without a credential are not bound
+	anonymous := initializeHTTP(t, url, nil)
+	if caller := callerOf(t, url, inSession(anonymous, nil)); caller != "" {
+		t.Errorf("unbound session runs as %q, want unauthenticated", caller)
+	}
+
+	// The bound credential is validated on every call
+	if err := s.st.RevokeTokens(context.Background(), alice.ID); err != nil {
+		t.Fatalf("RevokeTokens: %v", err)
+	}
+	if caller := callerOf(t, url, inSession(session, nil)); caller != "" {
+		t.Errorf("session with a revoked token runs as %q, want unauthenticated", caller)
+	}
+	if caller := callerOf(t, url, inSession(bobSession, nil)); caller != "bob" {
+		t.Errorf("bob's session runs as %q after revoking alice's tokens, want bob", caller)
+	}
+}
+
+func TestStatelessHTTPNotBound(t *testing.T) {
+	s := newTestServer(t, RegisterGetTokenInfoTool)
+	url := s.serveHTTP(t, server.WithStateLess(true))
+	_, token := s.addUser(t, "alice")
+
+	header := http.Header{"Authorization": {"Bearer " + token}}
+	if session := initializeHTTP(t, url, header); session != "" {
+		t.Errorf("stateless initialize returned session %s", session)
+	}
+	if caller := callerOf(t, url, nil); caller != "" {
+		t.Errorf("stateless call without credential runs as %q, want unauthenticated", caller)
+	}
+	if caller := callerOf(t, url, header); caller != "alice" {
+		t.Errorf("stateless call runs as %q, want alice", caller)
+	}
+}
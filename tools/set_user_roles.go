@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SetUserRolesInput represents the input for set_user_roles tool
+type SetUserRolesInput struct {
+	UserName string   `json:"user_name"`
+	Roles    []string `json:"roles"`
+}
+
+// RegisterSetUserRolesTool registers the set_user_roles tool
+func RegisterSetUserRolesTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("set_user_roles",
+		mcp.WithDescription("Replace the roles of a user (admin only)"),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the user"),
+		),
+		mcp.WithArray("roles",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Roles to assign. Available roles: %s. An empty list resets the user to the default '%s' role.", strings.Join(auth.ValidRoles(), ", "), auth.DefaultRole)),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithOutputSchema[models.UserWithRoles](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Parse input
+		var input SetUserRolesInput
+		inputBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			log.Printf("Error marshaling args: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+		if err := json.Unmarshal(inputBytes, &input); err != nil {
+			log.Printf("Error unmarshaling input: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+
+		// Validate required parameters
+		if input.UserName == "" {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+
+		isAdmin, roles, err := parseRoles(input.Roles)
+		if err != nil {
+			return errorResult(err, CodeInvalidInput), nil
+		}
+
+		// Find the user
+		user, err := st.GetUserByName(ctx, claims.OrgID, input.UserName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", input.UserName)), nil
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		userID := user.ID
+
+		// Prevent admins from locking themselves out
+		if userID == claims.UserID && !isAdmin {
+			return toolError(CodeConflict, "cannot remove your own admin role"), nil
+		}
+
+		if err := st.SetUserRoles(ctx, userID, isAdmin, roles); err != nil {
+			log.Printf("Error updating user roles: %v", err)
+			return toolError(CodeInternal, "failed to update roles"), nil
+		}
+
+		result, err := getOrgUser(ctx, st, claims.OrgID, userID)
+		if err != nil {
+			log.Printf("Error loading updated user: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("Roles of %s set to: %s", input.UserName, joinRoles(auth.EffectiveRoles(isAdmin, roles)))), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("set_user_roles tool registered")
+	return nil
+}
+
+// parseRoles validates role names and splits the admin role, which is stored
+// in users.is_admin, from the roles stored in user_roles
+func parseRoles(names []string) (bool, []auth.Role, error) {
+	isAdmin := false
+	var roles []auth.Role
+	seen := make(map[string]bool)
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !auth.IsValidRole(name) {
+			return false, nil, fmt.Errorf("invalid role: '%s'. Valid roles are: %s", name, strings.Join(auth.ValidRoles(), ", "))
+		}
+		if seen[name] {
+			return false, nil, fmt.Errorf("duplicate role: '%s'", name)
+		}
+		seen[name] = true
+
+		if auth.Role(name) == auth.RoleAdmin {
+			isAdmin = true
+			continue
+		}
+		roles = append(roles, auth.Role(name))
+	}
+
+	return isAdmin, roles, nil
+}
+
+// joinRoles formats roles as a comma-separated list
+func joinRoles(roles []auth.Role) string {
+	return strings.Join(roleNames(roles), ", ")
+}
+
+// roleNames converts roles to their names
+func roleNames(roles []auth.Role) []string {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	return names
+}
+
+// toRoles converts role names loaded from the store
+func toRoles(names []string) []auth.Role {
+	roles := make([]auth.Role, len(names))
+	for i, name := range names {
+		roles[i] = auth.Role(name)
+	}
+	return roles
+}
@@ -0,0 +1,262 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// URI templates of the task resources; all of them support subscriptions
+var (
+	taskURITemplate         = uritemplate.MustNew("task://{id}")
+	taskCommentsURITemplate = uritemplate.MustNew("task://{id}/comments")
+	taskProgressURITemplate = uritemplate.MustNew("task://{id}/progress")
+	userInboxURITemplate    = uritemplate.MustNew("user://{name}/inbox")
+)
+
+// subscriptionRegistry tracks the resources each session is subscribed to,
+// together with the claims of the subscriber. The claims are checked again
+// before every notification (see reauthorizeSubscription).
+type subscriptionRegistry struct {
+	mu       sync.RWMutex
+	sessions map[string]map[string]*auth.Claims
+}
+
+// subscriptions is the registry shared by the subscription hooks and the tools
+// that change tasks
+var subscriptions = &subscriptionRegistry{sessions: make(map[string]map[string]*auth.Claims)}
+
+// taskChange describes a change to a task for resource update notifications
+type taskChange struct {
+	OrgID        string
+	TaskID       string
+	AssigneeName string
+	// CommentAdded is set when the change added a comment to the task
+	CommentAdded bool
+	// Progress is set when the change reported progress on the task
+	Progress *models.TaskProgress
+}
+
+// addSubscriptionHooks records resources/subscribe and resources/unsubscribe
+// requests. Subscriptions are authorized like resource reads; unauthorized
+// subscriptions are acknowledged but never notified.
+func addSubscriptionHooks(hooks *server.Hooks, jwtManager *auth.JWTManager, st store.Store) {
+	hooks.AddAfterSubscribe(func(ctx context.Context, id any, request *mcp.SubscribeRequest, result *mcp.EmptyResult) {
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return
+		}
+
+		claims, err := authenticate(ctx, request.Header, jwtManager, st)
+		if err == nil {
+			err = auth.Authorize(claims, auth.PermTasksRead)
+		}
+		if err == nil {
+			err = authorizeSubscription(ctx, st, claims, request.Params.URI)
+		}
+		if err != nil {
+			log.Printf("Rejected subscription to %s: %v", request.Params.URI, err)
+			return
+		}
+
+		subscriptions.add(session.SessionID(), request.Params.URI, claims)
+	})
+
+	hooks.AddAfterUnsubscribe(func(ctx context.Context, id any, request *mcp.UnsubscribeRequest, result *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			subscriptions.remove(session.SessionID(), request.Params.URI)
+		}
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.removeSession(session.SessionID())
+	})
+}
+
+// authorizeSubscription checks that the caller may read the resource
+func authorizeSubscription(ctx context.Context, st store.Store, claims *auth.Claims, uri string) error {
+	if values := taskURITemplate.Match(uri); values != nil {
+		_, err := getViewableTask(ctx, st, claims, values.Get("id").String())
+		return err
+	}
+	if values := taskCommentsURITemplate.Match(uri); values != nil {
+		_, err := getViewableTask(ctx, st, claims, values.Get("id").String())
+		return err
+	}
+	if values := taskProgressURITemplate.Match(uri); values != nil {
+		_, err := getViewableTask(ctx, st, claims, values.Get("id").String())
+		return err
+	}
+	if values := userInboxURITemplate.Match(uri); values != nil {
+		userName := values.Get("name").String()
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err == store.ErrNotFound {
+			return newError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName))
+		}
+		if err != nil {
+			return err
+		}
+
+		canView, err := canViewTask(ctx, st, claims, user.ID, user.ID)
+		if err != nil {
+			return err
+		}
+		if !canView {
+			return newError(CodePermissionDenied, "permission denied")
+		}
+		return nil
+	}
+	return newError(CodeInvalidInput, fmt.Sprintf("resource %s does not support subscriptions", uri))
+}
+
+// add records a subscription of the session to the resource
+func (r *subscriptionRegistry) add(sessionID, uri string, claims *auth.Claims) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sessions[sessionID] == nil {
+		r.sessions[sessionID] = make(map[string]*auth.Claims)
+	}
+	r.sessions[sessionID][uri] = claims
+}
+
+// remove deletes a subscription of the session
+func (r *subscriptionRegistry) remove(sessionID, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions[sessionID], uri)
+	if len(r.sessions[sessionID]) == 0 {
+		delete(r.sessions, sessionID)
+	}
+}
+
+// drop deletes a subscription of the session that is no longer authorized,
+// unless the session subscribed again in the meantime
+func (r *subscriptionRegistry) drop(sessionID, uri string, claims *auth.Claims) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sessions[sessionID][uri] != claims {
+		return
+	}
+	delete(r.sessions[sessionID], uri)
+	if len(r.sessions[sessionID]) == 0 {
+		delete(r.sessions, sessionID)
+	}
+}
+
+// removeSession deletes all subscriptions of a closed session
+func (r *subscriptionRegistry) removeSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, sessionID)
+}
+
+// matches reports whether the subscribed resource is affected by the change
+func (c taskChange) matches(uri string) bool {
+	if values := taskURITemplate.Match(uri); values != nil {
+		return values.Get("id").String() == c.TaskID
+	}
+	if values := taskCommentsURITemplate.Match(uri); values != nil {
+		return c.CommentAdded && values.Get("id").String() == c.TaskID
+	}
+	if values := taskProgressURITemplate.Match(uri); values != nil {
+		return c.Progress != nil && values.Get("id").String() == c.TaskID
+	}
+	if values := userInboxURITemplate.Match(uri); values != nil {
+		return values.Get("name").String() == c.AssigneeName
+	}
+	return false
+}
+
+// reauthorizeSubscription checks that the subscriber may still read the
+// resource: the user may have been deleted or disabled, its token revoked or
+// expired, its API key revoked, or its roles or teams changed since it
+// subscribed
+func reauthorizeSubscription(ctx context.Context, st store.Store, subscribed *auth.Claims, uri string) error {
+	claims := *subscribed
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
+		return errors.New("token has expired")
+	}
+	if claims.APIKeyID != "" {
+		apiKey, err := st.GetAPIKey(ctx, claims.APIKeyID)
+		if err == store.ErrNotFound {
+			return errors.New("API key no longer exists")
+		}
+		if err != nil {
+			log.Printf("Error looking up API key: %v", err)
+			return errDatabase
+		}
+		if apiKey.RevokedAt.Valid {
+			return errors.New("API key has been revoked")
+		}
+	}
+	if err := refreshClaims(ctx, st, &claims); err != nil {
+		return err
+	}
+	if err := auth.Authorize(&claims, auth.PermTasksRead); err != nil {
+		return err
+	}
+	return authorizeSubscription(ctx, st, &claims, uri)
+}
+
+// notifyTaskChanged sends notifications/resources/updated to every session
+// subscribed to a resource affected by the change, once the subscriber has
+// been authorized again. Subscriptions that are no longer authorized are
+// dropped. Failures are logged, since the change itself has already been
+// committed.
+func notifyTaskChanged(ctx context.Context, st store.Store, change taskChange) {
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return
+	}
+
+	type notification struct {
+		sessionID string
+		uri       string
+		claims    *auth.Claims
+	}
+
+	var pending []notification
+	subscriptions.mu.RLock()
+	for sessionID, uris := range subscriptions.sessions {
+		for uri, claims := range uris {
+			if claims.OrgID == change.OrgID && change.matches(uri) {
+				pending = append(pending, notification{sessionID: sessionID, uri: uri, claims: claims})
+			}
+		}
+	}
+	subscriptions.mu.RUnlock()
+
+	for _, n := range pending {
+		if err := reauthorizeSubscription(ctx, st, n.claims, n.uri); err != nil {
+			// A failing store says nothing about the subscriber
+			if codeOf(err, CodePermissionDenied) == CodeInternal {
+				log.Printf("Error authorizing session %s for %s: %v", n.sessionID, n.uri, err)
+				continue
+			}
+			log.Printf("Dropped subscription of session %s to %s: %v", n.sessionID, n.uri, err)
+			subscriptions.drop(n.sessionID, n.uri, n.claims)
+			continue
+		}
+
+		err := mcpServer.SendNotificationToSpecificClient(n.sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": n.uri,
+		})
+		if err != nil {
+			log.Printf("Error notifying session %s about %s: %v", n.sessionID, n.uri, err)
+		}
+	}
+}
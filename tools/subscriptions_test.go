@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+func TestSubscriptionReauthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(s *testServer, user models.User) error
+	}{
+		{"still authorized", nil},
+		{"disabled", func(s *testServer, user models.User) error {
+			return s.st.SetUserDisabled(context.Background(), user.ID, true)
+		}},
+		{"tokens revoked", func(s *testServer, user models.User) error {
+			return s.st.RevokeTokens(context.Background(), user.ID)
+		}},
+		{"demoted", func(s *testServer, user models.User) error {
+			return s.st.SetUserRoles(context.Background(), user.ID, false, []auth.Role{auth.RoleViewer})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, RegisterCompleteTaskTool)
+			creator, _ := s.addUser(t, "creator")
+			assignee, assigneeToken := s.addUser(t, "assignee")
+			// A manager may read every task of the organization
+			manager, managerToken := s.addUser(t, "manager", auth.RoleManager)
+			taskID := s.addTask(t, creator.ID, assignee.ID, models.StatusPending)
+			session := s.subscribe(t, managerToken, "task://"+taskID)
+
+			if tt.revoke != nil {
+				if err := tt.revoke(s, manager); err != nil {
+					t.Fatalf("revoke: %v", err)
+				}
+			}
+
+			result := s.callTool(t, assigneeToken, "complete_task", map[string]any{"id": taskID, "result": "done"})
+			if result.IsError {
+				t.Fatalf("complete_task: %v", result.Content)
+			}
+
+			uris := session.updatedURIs()
+			if tt.revoke == nil {
+				if !slices.Equal(uris, []string{"task://" + taskID}) {
+					t.Errorf("notified about %v, want the task", uris)
+				}
+				return
+			}
+			if len(uris) != 0 {
+				t.Errorf("notified about %v after the subscriber lost access", uris)
+			}
+			subscriptions.mu.RLock()
+			_, subscribed := subscriptions.sessions[session.id]["task://"+taskID]
+			subscriptions.mu.RUnlock()
+			if subscribed {
+				t.Error("subscription kept after the subscriber lost access")
+			}
+		})
+	}
+}
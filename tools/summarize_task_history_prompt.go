@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterSummarizeTaskHistoryPrompt registers the summarize_task_history prompt
+func RegisterSummarizeTaskHistoryPrompt(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	prompt := mcp.NewPrompt("summarize_task_history",
+		mcp.WithPromptTitle("Summarise task history"),
+		mcp.WithPromptDescription("Load a task with its full comment thread and summarise what happened"),
+		mcp.WithArgument("task_id",
+			mcp.ArgumentDescription("Task ID (UUID)"),
+			mcp.RequiredArgument(),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest, claims *auth.Claims) (*mcp.GetPromptResult, error) {
+		task, err := getViewableTask(ctx, st, claims, request.Params.Arguments["task_id"])
+		if err != nil {
+			return nil, err
+		}
+
+		task.Comments, err = st.ListComments(ctx, task.ID)
+		if err != nil {
+			log.Printf("Error querying comments for task %s: %v", task.ID, err)
+			return nil, errDatabase
+		}
+
+		taskMessage, err := taskPromptMessage(task)
+		if err != nil {
+			return nil, err
+		}
+
+		instructions := `Summarise the history of the task below in a few sentences:
+- what was asked and by whom,
+- the questions and answers exchanged in the comments, in order,
+- the current status and result, and anything still open.`
+
+		return mcp.NewGetPromptResult(fmt.Sprintf("History of task: %s", task.Description), []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instructions)),
+			taskMessage,
+		}), nil
+	}
+
+	s.AddPrompt(prompt, promptWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("summarize_task_history prompt registered")
+	return nil
+}
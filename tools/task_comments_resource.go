@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TaskCommentsResource represents the contents of the task://{id}/comments resource
+type TaskCommentsResource struct {
+	TaskID   string                       `json:"task_id"`
+	Comments []models.TaskCommentWithUser `json:"comments"`
+	Count    int                          `json:"count"`
+}
+
+// RegisterTaskCommentsResource registers the task://{id}/comments resource template
+func RegisterTaskCommentsResource(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	template := mcp.NewResourceTemplate(taskCommentsURITemplate.Raw(), "Task comments",
+		mcp.WithTemplateDescription("The comments of a task in chronological order"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest, claims *auth.Claims) ([]mcp.ResourceContents, error) {
+		task, err := getViewableTask(ctx, st, claims, resourceArgument(request, "id"))
+		if err != nil {
+			return nil, err
+		}
+
+		comments, err := st.ListComments(ctx, task.ID)
+		if err != nil {
+			log.Printf("Error querying comments for task %s: %v", task.ID, err)
+			return nil, errDatabase
+		}
+
+		return jsonResourceContents(request.Params.URI, TaskCommentsResource{
+			TaskID:   task.ID,
+			Comments: comments,
+			Count:    len(comments),
+		})
+	}
+
+	s.AddResourceTemplate(template, resourceWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("task comments resource registered")
+	return nil
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TaskProgressResource represents the contents of the task://{id}/progress resource
+type TaskProgressResource struct {
+	TaskID  string                      `json:"task_id"`
+	Reports []models.TaskProgressReport `json:"reports"`
+	Count   int                         `json:"count"`
+}
+
+// RegisterTaskProgressResource registers the task://{id}/progress resource template
+func RegisterTaskProgressResource(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	template := mcp.NewResourceTemplate(taskProgressURITemplate.Raw(), "Task progress",
+		mcp.WithTemplateDescription("The progress reports of a task in chronological order"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest, claims *auth.Claims) ([]mcp.ResourceContents, error) {
+		task, err := getViewableTask(ctx, st, claims, resourceArgument(request, "id"))
+		if err != nil {
+			return nil, err
+		}
+
+		reports, err := st.ListProgress(ctx, task.ID)
+		if err != nil {
+			log.Printf("Error querying progress for task %s: %v", task.ID, err)
+			return nil, errDatabase
+		}
+
+		return jsonResourceContents(request.Params.URI, TaskProgressResource{
+			TaskID:  task.ID,
+			Reports: reports,
+			Count:   len(reports),
+		})
+	}
+
+	s.AddResourceTemplate(template, resourceWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("task progress resource registered")
+	return nil
+}
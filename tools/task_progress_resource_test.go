@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/models"
+)
+
+func TestTaskProgressResource(t *testing.T) {
+	s := newTestServer(t, RegisterTaskProgressResource)
+	creator, creatorToken := s.addUser(t, "creator")
+	assignee, _ := s.addUser(t, "assignee")
+	taskID := s.addTask(t, creator.ID, assignee.ID, models.StatusPending)
+
+	for _, report := range []struct {
+		percent int
+		message string
+	}{{10, "started"}, {60, ""}} {
+		if err := s.st.ReportProgress(context.Background(), taskID, assignee.ID, report.percent, report.message); err != nil {
+			t.Fatalf("ReportProgress: %v", err)
+		}
+	}
+
+	var resource TaskProgressResource
+	s.readResource(t, creatorToken, "task://"+taskID+"/progress", &resource)
+
+	if resource.TaskID != taskID || resource.Count != 2 || len(resource.Reports) != 2 {
+		t.Fatalf("resource = %+v, want 2 reports of %s", resource, taskID)
+	}
+	first, second := resource.Reports[0], resource.Reports[1]
+	if first.Percent != 10 || first.Message != "started" || first.CreatedByName != "assignee" {
+		t.Errorf("first report = %+v", first)
+	}
+	if second.Percent != 60 || second.Message != "" {
+		t.Errorf("second report = %+v", second)
+	}
+}
+
+func TestTaskProgressSubscription(t *testing.T) {
+	uri := "task://42/progress"
+	if !(taskChange{TaskID: "42", Progress: &models.TaskProgress{Percent: 5}}).matches(uri) {
+		t.Error("progress report does not match the progress resource")
+	}
+	if (taskChange{TaskID: "42", CommentAdded: true}).matches(uri) {
+		t.Error("comment matches the progress resource")
+	}
+}
@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// errTaskNotFound is returned for tasks that do not exist or belong to another organization
+var errTaskNotFound = newError(CodeNotFound, "task not found")
+
+// RegisterTaskResource registers the task://{id} resource template
+func RegisterTaskResource(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	template := mcp.NewResourceTemplate(taskURITemplate.Raw(), "Task",
+		mcp.WithTemplateDescription("A task with its creator, assignee, status, result and comments"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest, claims *auth.Claims) ([]mcp.ResourceContents, error) {
+		task, err := getViewableTask(ctx, st, claims, resourceArgument(request, "id"))
+		if err != nil {
+			return nil, err
+		}
+
+		task.Comments, err = st.ListComments(ctx, task.ID)
+		if err != nil {
+			log.Printf("Error querying comments for task %s: %v", task.ID, err)
+			return nil, errDatabase
+		}
+
+		return jsonResourceContents(request.Params.URI, task)
+	}
+
+	s.AddResourceTemplate(template, resourceWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("task resource registered")
+	return nil
+}
+
+// resourceArgument returns a variable matched from the resource URI template
+func resourceArgument(request mcp.ReadResourceRequest, name string) string {
+	switch value := request.Params.Arguments[name].(type) {
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	case string:
+		return value
+	}
+	return ""
+}
+
+// jsonResourceContents encodes a value as the JSON contents of a resource
+func jsonResourceContents(uri string, value interface{}) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// getViewableTask loads a task of the caller's organization and checks that
+// the caller may read it
+func getViewableTask(ctx context.Context, st store.Store, claims *auth.Claims, taskID string) (*models.TaskWithUsers, error) {
+	if !isValidUUID(taskID) {
+		return nil, newError(CodeInvalidInput, "invalid task ID format")
+	}
+
+	task, err := st.GetTask(ctx, claims.OrgID, taskID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, errTaskNotFound
+		}
+		log.Printf("Error getting task: %v", err)
+		return nil, errDatabase
+	}
+
+	canView, err := canViewTask(ctx, st, claims, task.CreatedByID, task.AssignedToID)
+	if err != nil {
+		log.Printf("Error checking task permission: %v", err)
+		return nil, errDatabase
+	}
+	if !canView {
+		return nil, newError(CodePermissionDenied, "permission denied: you can only view tasks you created, are assigned to, or that belong to your team")
+	}
+
+	return task, nil
+}
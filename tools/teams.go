@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/store"
+)
+
+// canManageTask reports whether the caller may change a task: its creator and
+// assignee can, as can users who manage all tasks and the leads of a team the
+// creator or assignee belongs to
+func canManageTask(ctx context.Context, st store.Store, claims *auth.Claims, createdBy, assignedTo string) (bool, error) {
+	if createdBy == claims.UserID || assignedTo == claims.UserID {
+		return true, nil
+	}
+	if claims.HasPermission(auth.PermTasksManageAll) {
+		return true, nil
+	}
+	return st.LeadsTeamOf(ctx, claims.UserID, createdBy, assignedTo)
+}
+
+// canViewTask reports whether the caller may read a task: everyone who can
+// manage it, plus users who can read all tasks
+func canViewTask(ctx context.Context, st store.Store, claims *auth.Claims, createdBy, assignedTo string) (bool, error) {
+	if claims.HasPermission(auth.PermTasksReadAll) {
+		return true, nil
+	}
+	return canManageTask(ctx, st, claims, createdBy, assignedTo)
+}
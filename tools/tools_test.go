@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/dushes/simple-task-mcp/store/memory"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testServer is an MCP server on an in-memory store with one organization
+type testServer struct {
+	mcp        *server.MCPServer
+	st         store.Store
+	jwtManager *auth.JWTManager
+	orgID      string
+}
+
+// newTestServer creates a server with the tools registered by the given
+// functions
+func newTestServer(t *testing.T, registers ...func(*server.MCPServer, *auth.JWTManager, store.Store) error) *testServer {
+	t.Helper()
+	st := memory.New()
+	org, err := st.EnsureOrganization(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("EnsureOrganization: %v", err)
+	}
+
+	jwtManager := auth.NewJWTManager("test-secret")
+	mcpServer := server.NewMCPServer("test", "0.0.0",
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
+		server.WithToolHandlerMiddleware(AuthMiddleware(jwtManager, st)),
+		server.WithHooks(Hooks(jwtManager, st)),
+	)
+	for _, register := range registers {
+		if err := register(mcpServer, jwtManager, st); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	return &testServer{mcp: mcpServer, st: st, jwtManager: jwtManager, orgID: org.ID}
+}
+
+// addUser creates a user with the roles and returns it with a token
+func (s *testServer) addUser(t *testing.T, name string, roles ...auth.Role) (models.User, string) {
+	t.Helper()
+	user := models.User{OrgID: s.orgID, Name: name}
+	if err := s.st.CreateUser(context.Background(), &user, roles); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	token, err := s.jwtManager.GenerateToken(user.ID, s.orgID, false, 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return user, token
+}
+
+// addAdmin creates an admin user and returns it with a token
+func (s *testServer) addAdmin(t *testing.T, name string) (models.User, string) {
+	t.Helper()
+	user := models.User{OrgID: s.orgID, Name: name, IsAdmin: true}
+	if err := s.st.CreateUser(context.Background(), &user, nil); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	token, err := s.jwtManager.GenerateToken(user.ID, s.orgID, true, 0)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	return user, token
+}
+
+// addAPIKey creates an API key for the user and returns its ID and the key
+func (s *testServer) addAPIKey(t *testing.T, userID string) (string, string) {
+	t.Helper()
+	keyID := uuid.New().String()
+	apiKey, keyHash, err := auth.GenerateAPIKey(keyID)
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if err := s.st.CreateAPIKey(context.Background(), &models.APIKey{ID: keyID, UserID: userID, Name: "key", KeyHash: keyHash}); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return keyID, apiKey
+}
+
+// addTask creates a task in the status
+func (s *testServer) addTask(t *testing.T, createdBy, assignedTo string, status models.TaskStatus) string {
+	t.Helper()
+	task := models.Task{
+		ID:          uuid.New().String(),
+		OrgID:       s.orgID,
+		Description: "test task",
+		Status:      status,
+		CreatedBy:   createdBy,
+		AssignedTo:  assignedTo,
+	}
+	if err := s.st.CreateTask(context.Background(), &task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return task.ID
+}
+
+// callTool calls a tool with the token like an MCP client without headers
+func (s *testServer) callTool(t *testing.T, token, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	return s.callToolContext(t, auth.WithToken(context.Background(), token), name, args)
+}
+
+// callToolContext calls a tool with the context, which carries the credential
+// and the session of the call
+func (s *testServer) callToolContext(t *testing.T, ctx context.Context, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params:  mcp.CallToolParams{Name: name, Arguments: args},
+	})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	response, ok := s.mcp.HandleMessage(ctx, message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s: unexpected response", name)
+	}
+	data, err := json.Marshal(response.Result)
+	if err != nil {
+		t.Fatalf("encode result: %v", err)
+	}
+	result, err := mcp.ParseCallToolResult((*json.RawMessage)(&data))
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	return result
+}
+
+// send sends a request with the token and returns its result, or the message
+// of the JSON-RPC error it failed with
+func (s *testServer) send(t *testing.T, token, method string, params any) (json.RawMessage, string) {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	switch response := s.mcp.HandleMessage(auth.WithToken(context.Background(), token), message).(type) {
+	case mcp.JSONRPCResponse:
+		data, err := json.Marshal(response.Result)
+		if err != nil {
+			t.Fatalf("encode result: %v", err)
+		}
+		return data, ""
+	case mcp.JSONRPCError:
+		return nil, response.Error.Message
+	default:
+		t.Fatalf("%s: unexpected response %T", method, response)
+		return nil, ""
+	}
+}
+
+// serveHTTP serves the MCP server over streamable HTTP with the options and
+// returns the URL of the endpoint
+func (s *testServer) serveHTTP(t *testing.T, opts ...server.StreamableHTTPOption) string {
+	t.Helper()
+	httpServer := server.NewTestStreamableHTTPServer(s.mcp, opts...)
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL
+}
+
+// postHTTP sends a JSON-RPC request with the headers to a streamable HTTP
+// endpoint and returns the result and the response headers
+func postHTTP(t *testing.T, url string, header http.Header, method string, params any) (json.RawMessage, http.Header) {
+	t.Helper()
+	message, err := json.Marshal(map[string]any{"jsonrpc": mcp.JSONRPC_VERSION, "id": 1, "method": method, "params": params})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(message))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json, text/event-stream")
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	defer response.Body.Close()
+
+	// The response is a JSON body or, when the server streams, an SSE event
+	var body []byte
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok && strings.Contains(data, `"id"`) {
+				body = []byte(data)
+				break
+			}
+		}
+	} else {
+		var buffer bytes.Buffer
+		buffer.ReadFrom(response.Body)
+		body = buffer.Bytes()
+	}
+
+	var decoded struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("%s: decode response %q (status %d): %v", method, body, response.StatusCode, err)
+	}
+	if decoded.Error != nil {
+		t.Fatalf("%s: %s", method, decoded.Error.Message)
+	}
+	return decoded.Result, response.Header
+}
+
+// callToolHTTP calls a tool over streamable HTTP with the headers
+func callToolHTTP(t *testing.T, url string, header http.Header, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	data, _ := postHTTP(t, url, header, string(mcp.MethodToolsCall), mcp.CallToolParams{Name: name, Arguments: args})
+	result, err := mcp.ParseCallToolResult(&data)
+	if err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	return result
+}
+
+// decodeResult decodes the structured content of a successful tool result
+func decodeResult(t *testing.T, result *mcp.CallToolResult, value any) {
+	t.Helper()
+	if result.IsError {
+		t.Fatalf("tool failed: %v", result.Content)
+	}
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		t.Fatalf("encode structured content: %v", err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		t.Fatalf("decode structured content: %v", err)
+	}
+}
+
+// readResource reads a resource with the token and decodes its JSON contents
+func (s *testServer) readResource(t *testing.T, token, uri string, value any) {
+	t.Helper()
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodResourcesRead)},
+		Params:  mcp.ReadResourceParams{URI: uri},
+	})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+
+	response, ok := s.mcp.HandleMessage(auth.WithToken(context.Background(), token), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s: unexpected response", uri)
+	}
+	data, err := json.Marshal(response.Result)
+	if err != nil {
+		t.Fatalf("encode result: %v", err)
+	}
+	var result struct {
+		Contents []struct {
+			Text string `json:"text"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil || len(result.Contents) != 1 {
+		t.Fatalf("decode result %s: %v", data, err)
+	}
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), value); err != nil {
+		t.Fatalf("decode contents: %v", err)
+	}
+}
+
+// testSession is a client session that collects the notifications sent to it
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// subscribe opens a session that subscribes to the resource with the token
+func (s *testServer) subscribe(t *testing.T, token, uri string) *testSession {
+	t.Helper()
+	session := &testSession{id: uuid.New().String(), notifications: make(chan mcp.JSONRPCNotification, 100)}
+	if err := s.mcp.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("RegisterSession: %v", err)
+	}
+	t.Cleanup(func() { s.mcp.UnregisterSession(context.Background(), session.id) })
+
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodResourcesSubscribe)},
+		Params:  mcp.SubscribeParams{URI: uri},
+	})
+	if err != nil {
+		t.Fatalf("encode request: %v", err)
+	}
+	ctx := auth.WithToken(s.mcp.WithContext(context.Background(), session), token)
+	if _, ok := s.mcp.HandleMessage(ctx, message).(mcp.JSONRPCResponse); !ok {
+		t.Fatalf("subscribe to %s: unexpected response", uri)
+	}
+	return session
+}
+
+// updatedURIs returns the URIs of the resources/updated notifications the
+// session received so far
+func (s *testSession) updatedURIs() []string {
+	var uris []string
+	for {
+		select {
+		case notification := <-s.notifications:
+			if notification.Method == string(mcp.MethodNotificationResourceUpdated) {
+				uri, _ := notification.Params.AdditionalFields["uri"].(string)
+				uris = append(uris, uri)
+			}
+		default:
+			return uris
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// triageLimit caps the number of tasks loaded into the triage prompt
+const triageLimit = 50
+
+// RegisterTriageCreatedTasksPrompt registers the triage_created_tasks prompt
+func RegisterTriageCreatedTasksPrompt(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	prompt := mcp.NewPrompt("triage_created_tasks",
+		mcp.WithPromptTitle("Triage my created tasks"),
+		mcp.WithPromptDescription("Load the open tasks you created and decide what needs your attention"),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest, claims *auth.Claims) (*mcp.GetPromptResult, error) {
+		tasks, err := st.ListTasks(ctx, store.TaskFilter{
+			OrgID:           claims.OrgID,
+			CreatedBy:       claims.UserID,
+			Statuses:        []models.TaskStatus{models.StatusPending, models.StatusInProgress, models.StatusWaitingForUser},
+			ExcludeArchived: true,
+			Limit:           triageLimit,
+		})
+		if err != nil {
+			log.Printf("Error querying created tasks: %v", err)
+			return nil, errDatabase
+		}
+
+		if len(tasks) == 0 {
+			return mcp.NewGetPromptResult("No open tasks", []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("I have no open tasks that I created. Tell me so and suggest creating one with create_task if needed.")),
+			}), nil
+		}
+
+		for i := range tasks {
+			tasks[i].Comments, err = st.ListComments(ctx, tasks[i].ID)
+			if err != nil {
+				log.Printf("Error querying comments for task %s: %v", tasks[i].ID, err)
+				return nil, errDatabase
+			}
+		}
+
+		data, err := json.MarshalIndent(tasks, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode tasks: %w", err)
+		}
+
+		instructions := fmt.Sprintf(`Triage the %d open tasks I created, listed below as JSON (oldest first).
+
+1. Tasks in waiting_for_user status are blocked on me: for each, quote the latest question and draft an answer that I can send with respond_to_task.
+2. Point out tasks that look stale (no update for a long time) or unclear, and suggest a follow-up.
+3. Suggest tasks that can be cancelled with cancel_task because they are obsolete or duplicated.
+4. End with a short prioritised list of what I should do next.
+
+%s`, len(tasks), string(data))
+
+		return mcp.NewGetPromptResult(fmt.Sprintf("Triage of %d open tasks", len(tasks)), []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instructions)),
+		}), nil
+	}
+
+	s.AddPrompt(prompt, promptWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("triage_created_tasks prompt registered")
+	return nil
+}
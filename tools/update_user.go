@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// UpdateUserInput represents the input for update_user tool
+type UpdateUserInput struct {
+	UserName    string  `json:"user_name"`
+	NewName     *string `json:"new_name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	IsAdmin     *bool   `json:"is_admin,omitempty"`
+}
+
+// RegisterUpdateUserTool registers the update_user tool
+func RegisterUpdateUserTool(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	tool := mcp.NewTool("update_user",
+		mcp.WithDescription("Update a user's name, description or admin privileges (admin only)"),
+		mcp.WithString("user_name",
+			mcp.Required(),
+			mcp.Description("Username of the user to update"),
+		),
+		mcp.WithString("new_name",
+			mcp.Description("New username"),
+		),
+		mcp.WithString("description",
+			mcp.Description("New description. Pass an empty string to clear it."),
+		),
+		mcp.WithBoolean("is_admin",
+			mcp.Description("Whether the user should have admin privileges"),
+		),
+		mcp.WithOutputSchema[models.UserWithRoles](),
+	)
+
+	handler := func(ctx context.Context, request mcp.CallToolRequest, claims *auth.Claims) (*mcp.CallToolResult, error) {
+		// Parse input
+		var input UpdateUserInput
+		inputBytes, err := json.Marshal(request.Params.Arguments)
+		if err != nil {
+			log.Printf("Error marshaling args: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+		if err := json.Unmarshal(inputBytes, &input); err != nil {
+			log.Printf("Error unmarshaling input: %v", err)
+			return toolError(CodeInvalidInput, fmt.Sprintf("Failed to parse input: %v", err)), nil
+		}
+
+		// Validate required parameters
+		if input.UserName == "" {
+			return toolError(CodeInvalidInput, "user_name is required"), nil
+		}
+		if input.NewName == nil && input.Description == nil && input.IsAdmin == nil {
+			return toolError(CodeInvalidInput, "nothing to update: provide new_name, description or is_admin"), nil
+		}
+		if input.NewName != nil && strings.TrimSpace(*input.NewName) == "" {
+			return toolError(CodeInvalidInput, "new_name cannot be empty"), nil
+		}
+
+		// Find the user
+		user, err := st.GetUserByName(ctx, claims.OrgID, input.UserName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return toolError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", input.UserName)), nil
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		userID := user.ID
+
+		// Prevent admins from locking themselves out
+		if userID == claims.UserID && input.IsAdmin != nil && !*input.IsAdmin {
+			return toolError(CodeConflict, "cannot remove your own admin privileges"), nil
+		}
+
+		// Update the provided fields only
+		update := store.UserUpdate{Description: input.Description, IsAdmin: input.IsAdmin}
+		if input.NewName != nil {
+			newName := strings.TrimSpace(*input.NewName)
+			update.Name = &newName
+		}
+		err = st.UpdateUser(ctx, userID, update)
+		if err != nil {
+			if err == store.ErrDuplicate {
+				return toolError(CodeConflict, fmt.Sprintf("user with name '%s' already exists", *input.NewName)), nil
+			}
+			log.Printf("Error updating user: %v", err)
+			return toolError(CodeInternal, "failed to update user"), nil
+		}
+
+		result, err := getOrgUser(ctx, st, claims.OrgID, userID)
+		if err != nil {
+			log.Printf("Error loading updated user: %v", err)
+			return toolError(CodeInternal, "database error"), nil
+		}
+
+		return mcp.NewToolResultStructured(result, fmt.Sprintf("User updated: %s (ID: %s)", result.Name, result.ID)), nil
+	}
+
+	s.AddTool(tool, requirePermission(auth.PermUsersManage, handler))
+	log.Println("update_user tool registered")
+	return nil
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// inboxLimit caps the number of tasks returned by the inbox resource
+const inboxLimit = 100
+
+// UserInboxResource represents the contents of the user://{name}/inbox resource
+type UserInboxResource struct {
+	UserID   string                 `json:"user_id"`
+	UserName string                 `json:"user_name"`
+	Tasks    []models.TaskWithUsers `json:"tasks"`
+	Count    int                    `json:"count"`
+}
+
+// RegisterUserInboxResource registers the user://{name}/inbox resource template
+func RegisterUserInboxResource(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	template := mcp.NewResourceTemplate(userInboxURITemplate.Raw(), "User inbox",
+		mcp.WithTemplateDescription("Open tasks assigned to a user, oldest first"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest, claims *auth.Claims) ([]mcp.ResourceContents, error) {
+		userName := resourceArgument(request, "name")
+
+		user, err := st.GetUserByName(ctx, claims.OrgID, userName)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return nil, newError(CodeNotFound, fmt.Sprintf("user '%s' does not exist", userName))
+			}
+			log.Printf("Error finding user by name: %v", err)
+			return nil, errDatabase
+		}
+
+		// Check if user can view other users' tasks
+		canView, err := canViewTask(ctx, st, claims, user.ID, user.ID)
+		if err != nil {
+			log.Printf("Error checking task permission: %v", err)
+			return nil, errDatabase
+		}
+		if !canView {
+			return nil, newError(CodePermissionDenied, "permission denied: you can only view the inbox of yourself or of your team members")
+		}
+
+		tasks, err := st.ListTasks(ctx, store.TaskFilter{
+			OrgID:           claims.OrgID,
+			AssignedTo:      user.ID,
+			Statuses:        []models.TaskStatus{models.StatusPending, models.StatusInProgress, models.StatusWaitingForUser},
+			ExcludeArchived: true,
+			Limit:           inboxLimit,
+		})
+		if err != nil {
+			log.Printf("Error querying inbox tasks: %v", err)
+			return nil, errDatabase
+		}
+
+		inbox := UserInboxResource{
+			UserID:   user.ID,
+			UserName: userName,
+			Tasks:    tasks,
+		}
+		inbox.Count = len(inbox.Tasks)
+
+		return jsonResourceContents(request.Params.URI, inbox)
+	}
+
+	s.AddResourceTemplate(template, resourceWithPermission(jwtManager, st, auth.PermTasksRead, handler))
+	log.Println("user inbox resource registered")
+	return nil
+}
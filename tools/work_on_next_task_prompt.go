@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dushes/simple-task-mcp/auth"
+	"github.com/dushes/simple-task-mcp/models"
+	"github.com/dushes/simple-task-mcp/store"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// RegisterWorkOnNextTaskPrompt registers the work_on_next_task prompt
+func RegisterWorkOnNextTaskPrompt(s *server.MCPServer, jwtManager *auth.JWTManager, st store.Store) error {
+	prompt := mcp.NewPrompt("work_on_next_task",
+		mcp.WithPromptTitle("Work on my next task"),
+		mcp.WithPromptDescription("Load your oldest open task with its comments and start working on it"),
+		mcp.WithArgument("status",
+			mcp.ArgumentDescription("Status of the task to pick up (default: pending)"),
+		),
+	)
+
+	handler := func(ctx context.Context, request mcp.GetPromptRequest, claims *auth.Claims) (*mcp.GetPromptResult, error) {
+		status := request.Params.Arguments["status"]
+		if status == "" {
+			status = string(models.StatusPending)
+		}
+		if !models.IsValidStatus(status) {
+			return nil, newError(CodeInvalidInput, fmt.Sprintf("invalid status: '%s'", status))
+		}
+
+		tasks, err := st.ListTasks(ctx, store.TaskFilter{
+			OrgID:           claims.OrgID,
+			AssignedTo:      claims.UserID,
+			Statuses:        []models.TaskStatus{models.TaskStatus(status)},
+			ExcludeArchived: true,
+			Limit:           1,
+		})
+		if err != nil {
+			log.Printf("Error querying next task: %v", err)
+			return nil, errDatabase
+		}
+		if len(tasks) == 0 {
+			return mcp.NewGetPromptResult("No task to work on", []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(fmt.Sprintf(
+					"I have no %s tasks right now. Call get_next_task with wait_seconds to wait until a task is assigned to me, then work on it.",
+					status,
+				))),
+			}), nil
+		}
+		task := &tasks[0]
+
+		task.Comments, err = st.ListComments(ctx, task.ID)
+		if err != nil {
+			log.Printf("Error querying comments for task %s: %v", task.ID, err)
+			return nil, errDatabase
+		}
+
+		taskMessage, err := taskPromptMessage(task)
+		if err != nil {
+			return nil, err
+		}
+
+		instructions := fmt.Sprintf(`Work on the task below, which %s assigned to me.
+
+1. Read the description and all comments; the latest comments may change the requirements.
+2. Do the work. On longer tasks, call report_progress with task ID %s, a percent and a short status so the creator can follow along.
+3. If you need information only the creator has, call wait_for_user with task ID %s and a question.
+4. When finished, call complete_task with task ID %s and a short result. If the task cannot be done, call cancel_task with the reason.`,
+			task.CreatedBy, task.ID, task.ID, task.ID)
+
+		return mcp.NewGetPromptResult(fmt.Sprintf("Work on task: %s", task.Description), []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(instructions)),
+			taskMessage,
+		}), nil
+	}
+
+	s.AddPrompt(prompt, promptWithPermission(jwtManager, st, auth.PermTasksWork, handler))
+	log.Println("work_on_next_task prompt registered")
+	return nil
+}
+
+// taskPromptMessage embeds a task as its task://{id} resource
+func taskPromptMessage(task *models.TaskWithUsers) (mcp.PromptMessage, error) {
+	data, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return mcp.PromptMessage{}, fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	return mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+		URI:      fmt.Sprintf("task://%s", task.ID),
+		MIMEType: "application/json",
+		Text:     string(data),
+	})), nil
+}